@@ -0,0 +1,77 @@
+package admin
+
+import (
+	"FastGin/pkg/auth"
+	"FastGin/pkg/bind"
+	"FastGin/pkg/config"
+	"FastGin/pkg/response"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateAPIKeyRequest 创建API密钥参数
+type CreateAPIKeyRequest struct {
+	Name      string                 `json:"name" binding:"required,max=64"`
+	Scopes    []config.APIKeyScope   `json:"scopes" binding:"required,min=1"`
+	AllowIPs  []string               `json:"allow_ips"`
+	RateLimit config.APIKeyRateLimit `json:"rate_limit"`
+	ExpiresAt *time.Time             `json:"expires_at"` // RFC3339格式，为空时永不过期
+}
+
+// CreateAPIKeyResponse 创建API密钥结果，明文密钥只在此时返回一次
+type CreateAPIKeyResponse struct {
+	*auth.APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyRequest API密钥ID参数
+type APIKeyRequest struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+// APIKeys 列出全部API密钥及其最近使用时间，不包含密钥明文和摘要
+func (h *Handler) APIKeys(c *gin.Context) error {
+	keys, err := h.keys.List(c.Request.Context())
+	if err != nil {
+		return err
+	}
+	response.OK(c, keys)
+	return nil
+}
+
+// CreateAPIKey 创建API密钥
+func (h *Handler) CreateAPIKey(c *gin.Context) error {
+	var req CreateAPIKeyRequest
+	if err := bind.Bind(c, &req); err != nil {
+		return err
+	}
+
+	key, plain, err := h.keys.Create(c.Request.Context(), auth.CreateAPIKeyParams{
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		AllowIPs:  req.AllowIPs,
+		RateLimit: req.RateLimit,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		return err
+	}
+	response.Sensitive(c)
+	response.Created(c, CreateAPIKeyResponse{APIKey: key, Key: plain})
+	return nil
+}
+
+// RevokeAPIKey 吊销API密钥，立即生效
+func (h *Handler) RevokeAPIKey(c *gin.Context) error {
+	var req APIKeyRequest
+	if err := bind.Bind(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.keys.Revoke(c.Request.Context(), req.ID); err != nil {
+		return err
+	}
+	response.OK(c, nil)
+	return nil
+}
@@ -0,0 +1,22 @@
+package admin
+
+import (
+	"FastGin/pkg/auth"
+	"FastGin/pkg/httpclient"
+)
+
+// Handler 运维管理接口
+type Handler struct {
+	clients *httpclient.Manager
+	keys    *auth.APIKeys
+	guard   *auth.LoginGuard
+}
+
+// NewHandler 创建运维管理处理器
+func NewHandler(clients *httpclient.Manager, keys *auth.APIKeys, guard *auth.LoginGuard) *Handler {
+	return &Handler{
+		clients: clients,
+		keys:    keys,
+		guard:   guard,
+	}
+}
@@ -0,0 +1,94 @@
+package admin
+
+import (
+	"FastGin/pkg/bind"
+	"FastGin/pkg/errcode"
+	"FastGin/pkg/httpclient"
+	"FastGin/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Proxies 出口代理池各代理的健康状态、成功率与延迟统计
+func (h *Handler) Proxies(c *gin.Context) error {
+	stats := []httpclient.ProxyStats{}
+	if pool := h.clients.ProxyPool(); pool != nil {
+		stats = pool.Stats()
+	}
+	response.OK(c, stats)
+	return nil
+}
+
+// UpstreamRequest 上游名称参数
+type UpstreamRequest struct {
+	Name string `uri:"name" binding:"required"`
+}
+
+// DebugRequest 切换上游调试日志参数
+type DebugRequest struct {
+	Name    string `uri:"name" binding:"required"`
+	Enabled bool   `json:"enabled"`
+	Dump    bool   `json:"dump"`
+}
+
+// UpstreamDebug 查看上游调试日志开关
+func (h *Handler) UpstreamDebug(c *gin.Context) error {
+	var req UpstreamRequest
+	if err := bind.Bind(c, &req); err != nil {
+		return err
+	}
+
+	client, ok := h.clients.Lookup(req.Name)
+	if !ok {
+		return errcode.NotFound
+	}
+	response.OK(c, client.Debug())
+	return nil
+}
+
+// SetUpstreamDebug 运行时切换上游调试日志，dump为true时同时写入完整转储文件
+func (h *Handler) SetUpstreamDebug(c *gin.Context) error {
+	var req DebugRequest
+	if err := bind.Bind(c, &req); err != nil {
+		return err
+	}
+
+	client, ok := h.clients.Lookup(req.Name)
+	if !ok {
+		return errcode.NotFound
+	}
+	client.SetDebug(httpclient.DebugState{Enabled: req.Enabled, Dump: req.Dump})
+	response.OK(c, client.Debug())
+	return nil
+}
+
+// PurgeCacheRequest 清除上游响应缓存参数
+type PurgeCacheRequest struct {
+	Name string `uri:"name" binding:"required"`
+	Path string `form:"path"` // 为空时清除该上游的全部缓存
+}
+
+// CacheStats 各上游响应缓存的命中、未命中和重新验证统计
+func (h *Handler) CacheStats(c *gin.Context) error {
+	response.OK(c, h.clients.CacheStats())
+	return nil
+}
+
+// PurgeCache 清除指定上游的响应缓存
+func (h *Handler) PurgeCache(c *gin.Context) error {
+	var req PurgeCacheRequest
+	if err := bind.Bind(c, &req); err != nil {
+		return err
+	}
+
+	client, ok := h.clients.Lookup(req.Name)
+	if !ok {
+		return errcode.NotFound
+	}
+	n, err := client.PurgeCache(c.Request.Context(), req.Path)
+	if err != nil {
+		return errcode.InternalServerError.Wrap(err)
+	}
+	response.OK(c, gin.H{"purged": n})
+	return nil
+}
@@ -0,0 +1,29 @@
+package admin
+
+import (
+	"FastGin/pkg/auth"
+	"FastGin/pkg/bind"
+	"FastGin/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UnlockLoginRequest 解除登录锁定参数，用户名和IP至少传一个
+type UnlockLoginRequest struct {
+	Username string `form:"username" binding:"required_without=IP"`
+	IP       string `form:"ip" binding:"omitempty,ip"`
+}
+
+// UnlockLogin 解除用户名或来源IP的登录锁定并清除失败次数
+func (h *Handler) UnlockLogin(c *gin.Context) error {
+	var req UnlockLoginRequest
+	if err := bind.Bind(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.guard.Unlock(c.Request.Context(), req.Username, req.IP, auth.Principal(c)); err != nil {
+		return err
+	}
+	response.OK(c, nil)
+	return nil
+}
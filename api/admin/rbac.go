@@ -0,0 +1,31 @@
+package admin
+
+import (
+	"FastGin/pkg/auth"
+	"FastGin/pkg/bind"
+	"FastGin/pkg/rbac"
+	"FastGin/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RBACCheckRequest 权限检查参数
+type RBACCheckRequest struct {
+	Subject    string `form:"subject"` // 为空时检查当前调用方
+	Permission string `form:"permission" binding:"required"`
+}
+
+// RBACCheck 检查主体是否拥有指定权限，返回主体的角色、命中的规则和拒绝原因
+func (h *Handler) RBACCheck(c *gin.Context) error {
+	var req RBACCheckRequest
+	if err := bind.Bind(c, &req); err != nil {
+		return err
+	}
+
+	subject := req.Subject
+	if subject == "" {
+		subject = auth.Principal(c)
+	}
+	response.OK(c, rbac.Check(subject, req.Permission))
+	return nil
+}
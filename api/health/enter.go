@@ -0,0 +1,9 @@
+package health
+
+// Handler 健康检查接口
+type Handler struct{}
+
+// NewHandler 创建健康检查处理器
+func NewHandler() *Handler {
+	return &Handler{}
+}
@@ -0,0 +1,36 @@
+package health
+
+import (
+	"FastGin/pkg/errcode"
+	"FastGin/pkg/health"
+	"FastGin/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LivenessResponse 存活检查结果
+type LivenessResponse struct {
+	Status string `json:"status"`
+}
+
+// Healthz 存活检查，只表示进程能处理请求，不检查依赖，避免依赖故障导致实例被反复重启
+func (h *Handler) Healthz(c *gin.Context) error {
+	response.OK(c, LivenessResponse{Status: health.StatusOK})
+	return nil
+}
+
+// Readyz 就绪检查，执行关键检查，失败或服务正在关闭时返回503，负载均衡据此摘除实例
+func (h *Handler) Readyz(c *gin.Context) error {
+	report := health.Ready(c.Request.Context())
+	if report.Status == health.StatusFail {
+		return errcode.NotReady.WithDetails(report)
+	}
+	response.OK(c, report)
+	return nil
+}
+
+// Details 返回版本、构建信息、运行时长和全部检查结果，非关键检查失败时状态为 degraded
+func (h *Handler) Details(c *gin.Context) error {
+	response.OK(c, health.Detail(c.Request.Context()))
+	return nil
+}
@@ -0,0 +1,9 @@
+package security
+
+// Handler 安全相关的公开接口
+type Handler struct{}
+
+// NewHandler 创建安全接口处理器
+func NewHandler() *Handler {
+	return &Handler{}
+}
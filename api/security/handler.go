@@ -0,0 +1,101 @@
+package security
+
+import (
+	"FastGin/pkg/errcode"
+	"FastGin/pkg/logg"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxReportSize 单次违规报告请求体的最大字节数
+const maxReportSize = 64 << 10
+
+// legacyReport report-uri 上报的违规报告，Content-Type 为 application/csp-report
+type legacyReport struct {
+	Report struct {
+		DocumentURI        string `json:"document-uri"`
+		BlockedURI         string `json:"blocked-uri"`
+		ViolatedDirective  string `json:"violated-directive"`
+		EffectiveDirective string `json:"effective-directive"`
+		Disposition        string `json:"disposition"`
+		SourceFile         string `json:"source-file"`
+		LineNumber         int    `json:"line-number"`
+		ColumnNumber       int    `json:"column-number"`
+		ScriptSample       string `json:"script-sample"`
+	} `json:"csp-report"`
+}
+
+// reportingReport report-to 通过Reporting API上报的报告，Content-Type 为 application/reports+json
+type reportingReport struct {
+	Type string `json:"type"`
+	Body struct {
+		DocumentURL        string `json:"documentURL"`
+		BlockedURL         string `json:"blockedURL"`
+		EffectiveDirective string `json:"effectiveDirective"`
+		Disposition        string `json:"disposition"`
+		SourceFile         string `json:"sourceFile"`
+		LineNumber         int    `json:"lineNumber"`
+		ColumnNumber       int    `json:"columnNumber"`
+		Sample             string `json:"sample"`
+	} `json:"body"`
+}
+
+// CSPReport 接收浏览器上报的CSP违规报告并写入警告日志，兼容 report-uri 和 report-to 两种格式
+func (h *Handler) CSPReport(c *gin.Context) error {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxReportSize))
+	if err != nil {
+		return errcode.InvalidParams.Wrap(err)
+	}
+
+	var violations []map[string]interface{}
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		var reports []reportingReport
+		if err := json.Unmarshal(trimmed, &reports); err != nil {
+			return errcode.InvalidParams.Wrap(err)
+		}
+		for _, r := range reports {
+			if r.Type != "csp-violation" {
+				continue
+			}
+			violations = append(violations, map[string]interface{}{
+				"document_uri":        r.Body.DocumentURL,
+				"blocked_uri":         r.Body.BlockedURL,
+				"effective_directive": r.Body.EffectiveDirective,
+				"disposition":         r.Body.Disposition,
+				"source_file":         r.Body.SourceFile,
+				"line":                r.Body.LineNumber,
+				"column":              r.Body.ColumnNumber,
+				"sample":              r.Body.Sample,
+			})
+		}
+	} else {
+		var r legacyReport
+		if err := json.Unmarshal(trimmed, &r); err != nil {
+			return errcode.InvalidParams.Wrap(err)
+		}
+		violations = append(violations, map[string]interface{}{
+			"document_uri":        r.Report.DocumentURI,
+			"blocked_uri":         r.Report.BlockedURI,
+			"violated_directive":  r.Report.ViolatedDirective,
+			"effective_directive": r.Report.EffectiveDirective,
+			"disposition":         r.Report.Disposition,
+			"source_file":         r.Report.SourceFile,
+			"line":                r.Report.LineNumber,
+			"column":              r.Report.ColumnNumber,
+			"sample":              r.Report.ScriptSample,
+		})
+	}
+
+	for _, v := range violations {
+		v["request_id"] = c.GetString("RequestID")
+		v["client_ip"] = c.ClientIP()
+		v["user_agent"] = c.Request.UserAgent()
+		logg.Warn("CSP违规报告", v)
+	}
+	c.Status(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,80 @@
+package security
+
+import (
+	"FastGin/middleware"
+	"FastGin/pkg/errcode"
+	"FastGin/pkg/testutil"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	testutil.Main(m)
+}
+
+// newReportRouter 与正式路由一致，日志中间件位于报告接口之前
+func newReportRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	testutil.InTempDir(t)
+	r := gin.New()
+	r.Use(middleware.LoggerMiddleware(), middleware.ErrorHandler())
+	r.POST("/csp-report", errcode.Handle(NewHandler().CSPReport))
+	return r
+}
+
+func postReport(r *gin.Engine, contentType, body string) int {
+	req := httptest.NewRequest(http.MethodPost, "/csp-report", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestCSPReport(t *testing.T) {
+	r := newReportRouter(t)
+
+	legacy := `{"csp-report":{"document-uri":"https://example.com/","blocked-uri":"inline","violated-directive":"script-src"}}`
+	if code := postReport(r, "application/csp-report", legacy); code != http.StatusNoContent {
+		t.Fatalf("report-uri status %d", code)
+	}
+	reporting := `[{"type":"csp-violation","body":{"documentURL":"https://example.com/","effectiveDirective":"img-src"}}]`
+	if code := postReport(r, "application/reports+json", reporting); code != http.StatusNoContent {
+		t.Fatalf("report-to status %d", code)
+	}
+}
+
+// countingReader 统计从请求体中读取的字节数
+type countingReader struct {
+	r    io.Reader
+	read int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += n
+	return n, err
+}
+
+func TestCSPReportTooLarge(t *testing.T) {
+	r := newReportRouter(t)
+
+	sample := strings.Repeat("a", 16*maxReportSize)
+	body := &countingReader{r: strings.NewReader(`{"csp-report":{"script-sample":"` + sample + `"}}`)}
+	req := httptest.NewRequest(http.MethodPost, "/csp-report", body)
+	req.Header.Set("Content-Type", "application/csp-report")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, want 400", w.Code)
+	}
+	// 日志中间件只缓存有限的请求体，超出报告大小限制后不再继续读取
+	if body.read >= len(sample) {
+		t.Fatalf("read %d bytes of an oversized report, limit %d", body.read, maxReportSize)
+	}
+}
@@ -1,22 +1,58 @@
 package test
 
 import (
-	"FastGin/api/common"
 	"FastGin/apiServer"
+	"FastGin/pkg/auth"
+	"FastGin/pkg/errcode"
+	"FastGin/pkg/httpclient"
+	"context"
+	"errors"
+	"net/http"
+)
+
+// 模块错误码
+var (
+	ErrLoginFailed        = errcode.Register(20001, http.StatusInternalServerError, "error.login_failed", "登录失败")
+	ErrInvalidCredentials = errcode.Register(20002, http.StatusUnauthorized, "error.invalid_credentials", "用户名或密码错误")
 )
 
 type Handler struct {
 	service ExampleServiceInterface
+	auth    *auth.Authenticator
+	users   auth.CredentialVerifier
+	guard   *auth.LoginGuard
 }
 
 // ExampleServiceInterface 定义服务接口
 type ExampleServiceInterface interface {
-	Login(params map[string]string) (*common.Response, error)
+	Login(ctx context.Context, params map[string]string) (*apiServer.LoginResult, error)
 }
 
-// NewHandler 创建新的处理器实例
-func NewHandler() *Handler {
+// NewHandler 创建新的处理器实例，users用于校验登录密码，authenticator用于登录成功后签发令牌，guard用于登录失败保护
+func NewHandler(service ExampleServiceInterface, authenticator *auth.Authenticator, users auth.CredentialVerifier, guard *auth.LoginGuard) *Handler {
 	return &Handler{
-		service: apiServer.NewExampleService(),
+		service: service,
+		auth:    authenticator,
+		users:   users,
+		guard:   guard,
+	}
+}
+
+// upstreamError 将上游调用错误映射为对应的业务错误码和HTTP状态码
+func upstreamError(err error) error {
+	var upErr *httpclient.UpstreamError
+	if !errors.As(err, &upErr) {
+		return ErrLoginFailed.Wrap(err)
+	}
+
+	switch upErr.Kind {
+	case httpclient.KindTimeout:
+		return errcode.UpstreamTimeout.Wrap(err)
+	case httpclient.KindClient:
+		return errcode.UpstreamRejected.Wrap(err)
+	case httpclient.KindServer, httpclient.KindDecode:
+		return errcode.UpstreamBadGateway.Wrap(err)
+	default:
+		return errcode.UpstreamUnavailable.Wrap(err)
 	}
 }
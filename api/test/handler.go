@@ -1,10 +1,17 @@
 package test
 
 import (
-	"FastGin/api/common"
+	"FastGin/apiServer"
+	"FastGin/pkg/auth"
+	"FastGin/pkg/bind"
+	"FastGin/pkg/errcode"
+	"FastGin/pkg/httpclient"
+	"FastGin/pkg/response"
+	"errors"
 	"fmt"
+	"strconv"
+
 	"github.com/gin-gonic/gin"
-	"net/http"
 )
 
 // LoginRequest 登录请求参数
@@ -13,17 +20,53 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
-// Login 处理登录请求
-func (h *Handler) Login(c *gin.Context) {
+// RefreshRequest 刷新令牌请求参数
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LoginResponse 登录成功响应，包含签发的令牌和上游登录结果
+type LoginResponse struct {
+	*auth.TokenPair
+	Upstream *apiServer.LoginResult `json:"upstream,omitempty"`
+}
+
+// Login 处理登录请求，校验用户名和密码后建立上游会话，上游登录成功后签发访问令牌和刷新令牌
+// 用户名或来源IP连续失败后需要等待或被临时锁定，此时不再校验密码
+func (h *Handler) Login(c *gin.Context) error {
 	var req LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.Error(fmt.Errorf("参数解析失败: %v", err))
-		c.JSON(http.StatusBadRequest, common.Response{
-			Code:    400,
-			Message: "参数错误",
-			Data:    err.Error(),
-		})
-		return
+	if err := bind.Bind(c, &req); err != nil {
+		return err
+	}
+
+	// 同一用户名同时只处理一个登录请求，并发请求在本次结果记录前无法通过检查
+	status, done, err := h.guard.Begin(c.Request.Context(), req.Username, c.ClientIP())
+	if err != nil {
+		return err
+	}
+	defer done()
+	if status.Blocked() {
+		c.Header("Retry-After", strconv.Itoa(status.RetryAfter))
+		return status.Err()
+	}
+
+	// 密码错误计入失败次数，校验通过前不调用上游，避免为未认证的用户名建立上游会话
+	if err := h.users.Verify(c.Request.Context(), req.Username, req.Password); err != nil {
+		if !errors.Is(err, auth.ErrBadCredentials) {
+			return errcode.InternalServerError.Wrap(err)
+		}
+		status, err := h.guard.Fail(c.Request.Context(), req.Username, c.ClientIP())
+		if err != nil {
+			return err
+		}
+		if status.RetryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(status.RetryAfter))
+		}
+		// 详情只包含失败保护状态，不区分用户名不存在和密码错误
+		return ErrInvalidCredentials.WithDetails(status)
+	}
+	if err := h.guard.Succeed(c.Request.Context(), req.Username); err != nil {
+		return err
 	}
 
 	params := map[string]string{
@@ -32,16 +75,55 @@ func (h *Handler) Login(c *gin.Context) {
 		"uid":      req.Username, // 使用username作为uid传递给服务
 	}
 
-	result, err := h.service.Login(params)
+	// 上游调用随入站请求取消，并在日志中关联请求ID
+	ctx := httpclient.WithRequestID(c.Request.Context(), c.GetString("RequestID"))
+	result, err := h.service.Login(ctx, params)
+	if err != nil {
+		return upstreamError(err)
+	}
+	// 上游的业务错误只写入日志，不返回给调用方
+	if result.Code != 0 {
+		return errcode.UpstreamRejected.Wrap(fmt.Errorf("上游登录失败: code=%d msg=%s", result.Code, result.Msg))
+	}
+
+	tokens, err := h.auth.Issue(req.Username)
+	if err != nil {
+		return errcode.InternalServerError.Wrap(err)
+	}
+
+	// 响应包含令牌，不写入请求日志
+	response.Sensitive(c)
+	response.OK(c, LoginResponse{TokenPair: tokens, Upstream: result})
+	return nil
+}
+
+// Refresh 使用刷新令牌换取新的令牌对，每个刷新令牌只能使用一次
+func (h *Handler) Refresh(c *gin.Context) error {
+	var req RefreshRequest
+	if err := bind.Bind(c, &req); err != nil {
+		return err
+	}
+
+	tokens, err := h.auth.Refresh(c.Request.Context(), req.RefreshToken)
 	if err != nil {
-		c.Error(fmt.Errorf("登录服务调用失败: %v", err))
-		c.JSON(http.StatusInternalServerError, common.Response{
-			Code:    500,
-			Message: "登录失败",
-			Data:    nil,
-		})
-		return
+		return err
+	}
+
+	response.Sensitive(c)
+	response.OK(c, tokens)
+	return nil
+}
+
+// Logout 退出登录，吊销当前访问令牌及其令牌族，同一次登录签发的刷新令牌随之失效
+func (h *Handler) Logout(c *gin.Context) error {
+	claims, ok := auth.ClaimsFrom(c)
+	if !ok {
+		return errcode.Unauthorized
+	}
+	if err := h.auth.Revoke(c.Request.Context(), claims); err != nil {
+		return err
 	}
 
-	c.JSON(http.StatusOK, *result)
+	response.OK(c, nil)
+	return nil
 }
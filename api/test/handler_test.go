@@ -0,0 +1,147 @@
+package test
+
+import (
+	"FastGin/apiServer"
+	"FastGin/middleware"
+	"FastGin/pkg/auth"
+	"FastGin/pkg/config"
+	"FastGin/pkg/errcode"
+	"FastGin/pkg/httpclient"
+	"FastGin/pkg/testutil"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testPasswordHash 密码 secret123 的bcrypt摘要
+const testPasswordHash = "$2a$10$o9OPATKE7GsvXBEiuH/Fwefj9Pv2xrpbED7aoc.hZdu333wggEHtS"
+
+func TestMain(m *testing.M) {
+	testutil.Main(m)
+}
+
+// countingService 统计上游登录调用次数
+type countingService struct {
+	ExampleServiceInterface
+	calls int
+}
+
+func (s *countingService) Login(ctx context.Context, params map[string]string) (*apiServer.LoginResult, error) {
+	s.calls++
+	return s.ExampleServiceInterface.Login(ctx, params)
+}
+
+// newTestRouter 创建登录路由，上游调用回放 testdata/cassettes/login.json
+func newTestRouter(t *testing.T) (*gin.Engine, *countingService) {
+	t.Helper()
+	client, err := httpclient.New(apiServer.ExampleUpstream, testutil.ReplayUpstream("testdata/cassettes/login.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+
+	authenticator, err := auth.New(config.AuthConfig{
+		Issuer:    "fastgin-test",
+		ActiveKey: "test",
+		Keys:      []config.JWTKeyConfig{{KID: "test", Alg: "HS256", Secret: strings.Repeat("k", 32)}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, err := auth.NewUsers([]config.UserConfig{
+		{Username: "alice", PasswordHash: testPasswordHash},
+		{Username: "bob", PasswordHash: testPasswordHash},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := &countingService{ExampleServiceInterface: apiServer.NewExampleService(client)}
+	h := NewHandler(service, authenticator, users, auth.NewLoginGuard(config.LoginGuardConfig{}, nil))
+
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	r.POST("/login", errcode.Handle(h.Login))
+	return r, service
+}
+
+type loginBody struct {
+	Code int `json:"code"`
+	Data struct {
+		AccessToken  string                 `json:"access_token"`
+		RefreshToken string                 `json:"refresh_token"`
+		Upstream     *apiServer.LoginResult `json:"upstream"`
+	} `json:"data"`
+}
+
+func login(t *testing.T, r *gin.Engine, username, password string) (int, loginBody) {
+	t.Helper()
+	payload := fmt.Sprintf(`{"username":%q,"password":%q}`, username, password)
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var body loginBody
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode %q: %v", w.Body.String(), err)
+	}
+	return w.Code, body
+}
+
+func TestLoginReloginOnAuthFailure(t *testing.T) {
+	r, service := newTestRouter(t)
+
+	// 录制文件中首次下单返回401，会话重新登录后重试成功，业务代码只调用一次
+
+	status, body := login(t, r, "alice", "secret123")
+	if status != http.StatusOK || body.Code != 0 {
+		t.Fatalf("status %d code %d", status, body.Code)
+	}
+	if body.Data.AccessToken == "" || body.Data.RefreshToken == "" {
+		t.Fatal("tokens not issued")
+	}
+	if body.Data.Upstream == nil || body.Data.Upstream.Status != "SUCCESS" {
+		t.Fatalf("upstream %+v", body.Data.Upstream)
+	}
+	if service.calls != 1 {
+		t.Fatalf("upstream calls %d, want 1", service.calls)
+	}
+}
+
+func TestLoginBadPassword(t *testing.T) {
+	r, service := newTestRouter(t)
+
+	status, body := login(t, r, "alice", "wrong")
+	if status != http.StatusUnauthorized || body.Code != ErrInvalidCredentials.Code {
+		t.Fatalf("status %d code %d", status, body.Code)
+	}
+	if body.Data.AccessToken != "" {
+		t.Fatal("token issued for bad password")
+	}
+	if service.calls != 0 {
+		t.Fatalf("upstream called %d times before password check", service.calls)
+	}
+}
+
+func TestLoginUnmatchedUpstream(t *testing.T) {
+	r, service := newTestRouter(t)
+
+	// bob 密码正确，但录制文件中没有其上游请求，回放失败时不签发令牌
+	status, body := login(t, r, "bob", "secret123")
+	if status != http.StatusServiceUnavailable || body.Code != errcode.UpstreamUnavailable.Code {
+		t.Fatalf("status %d code %d", status, body.Code)
+	}
+	if body.Data.AccessToken != "" {
+		t.Fatal("token issued without upstream session")
+	}
+	if service.calls != 1 {
+		t.Fatalf("upstream calls %d, want 1", service.calls)
+	}
+}
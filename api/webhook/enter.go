@@ -0,0 +1,9 @@
+package webhook
+
+// Handler 合作方回调接口，路由组已通过签名验证中间件
+type Handler struct{}
+
+// NewHandler 创建合作方回调处理器
+func NewHandler() *Handler {
+	return &Handler{}
+}
@@ -0,0 +1,24 @@
+package webhook
+
+import (
+	"FastGin/pkg/response"
+	"FastGin/pkg/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PingResponse 签名联调结果
+type PingResponse struct {
+	Partner string `json:"partner"`
+	Bytes   int    `json:"bytes"` // 收到的请求体长度
+}
+
+// Ping 供合作方联调签名实现，签名验证通过后返回合作方ID和收到的请求体长度
+func (h *Handler) Ping(c *gin.Context) error {
+	body, err := c.GetRawData()
+	if err != nil {
+		return err
+	}
+	response.OK(c, PingResponse{Partner: webhook.PartnerFrom(c), Bytes: len(body)})
+	return nil
+}
@@ -1,77 +1,104 @@
 package apiServer
 
 import (
-	"FastGin/api/common"
+	"FastGin/pkg/httpclient"
+	"context"
+	"encoding/json"
 	"fmt"
-	"github.com/imroc/req/v3"
 	"net/http"
+
+	"github.com/imroc/req/v3"
 )
 
+// ExampleUpstream 示例服务使用的上游名称，对应settings.yaml中upstreams的键
+const ExampleUpstream = "lkcoffee"
+
+// sessionLoginPath 上游账号登录接口，登录成功后通过Set-Cookie下发会话
+const sessionLoginPath = "/resource/user/v1/login"
+
+// credentialsKey 上游登录凭据在请求上下文中的键，供会话登录回调读取
+type credentialsKey struct{}
+
+// LoginResult 上游登录接口响应
+type LoginResult struct {
+	Code    int             `json:"code"`
+	Msg     string          `json:"msg"`
+	Status  string          `json:"status,omitempty"`
+	Content json.RawMessage `json:"content,omitempty"`
+}
+
 // ExampleService 示例服务结构体
 type ExampleService struct {
-	client *req.Client
+	client *httpclient.Client
 }
 
 // NewExampleService 创建示例服务实例
-func NewExampleService() *ExampleService {
-	return &ExampleService{
-		client: req.C(),
+// 会话不存在、过期或上游返回鉴权失败时，使用本次调用的账号密码自动重新登录
+func NewExampleService(client *httpclient.Client) *ExampleService {
+	s := &ExampleService{
+		client: client,
 	}
+	client.Sessions().SetHooks(httpclient.SessionHooks{
+		Login:         s.loginSession,
+		IsAuthFailure: isAuthFailure,
+	})
+	return s
 }
 
 // Login 登录接口实现
-func (s *ExampleService) Login(params map[string]string) (*common.Response, error) {
-	// 创建新的HTTP客户端
-	client := req.C()
-
-	// 设置HTTP2
-	client.EnableForceHTTP2()
-
+// 失败时返回*httpclient.UpstreamError，区分传输错误、超时、上游4xx和5xx
+func (s *ExampleService) Login(ctx context.Context, params map[string]string) (*LoginResult, error) {
 	// 定义URL
-	url := "https://capi.lkcoffee.com/resource/core/v1/order/create"
+	url := "/resource/core/v1/order/create"
 
-	// 准备表单数据
+	// 准备表单数据，时间戳和签名由客户端签名中间件按上游配置生成
 	formData := map[string]string{
-		"sign": "630325412989152581553297576247734904",
-		"uid":  params["uid"],
-		"t":    "1742867714121",
-		"cid":  "210101",
+		"uid": params["uid"],
+		"cid": "210101",
 	}
 
-	// 设置Cookie
-	uidCookie := &http.Cookie{
-		Name:  "uid",
-		Value: "bf7dd9ba-3c02-4e4d-bc37-e06a46ddcd6b1742261779418",
+	// 按账号获取上游会话，Cookie由会话自动携带和保存，需要登录时回调从ctx读取凭据
+	ctx = context.WithValue(ctx, credentialsKey{}, params)
+	session, err := s.client.Session(params["uid"])
+	if err != nil {
+		return nil, err
 	}
 
-	// 发送请求
-	resp, err := client.R().
-		SetHeaders(map[string]string{
-			"User-Agent":      "okhttp/4.9.3",
-			"Accept-Encoding": "gzip",
-			"Content-Type":    "application/x-www-form-urlencoded",
-			"x-lk-akv":        "5205",
-		}).
-		SetCookies(uidCookie).
+	// 发送请求，公共请求头由上游配置提供
+	resp, err := session.RWithContext(ctx).
+		SetHeader("Content-Type", "application/x-www-form-urlencoded").
 		SetFormData(formData).
 		Post(url)
+	if err := s.client.Check(resp, err); err != nil {
+		return nil, err
+	}
 
-	// 记录响应
-	fmt.Printf("响应体: %s\n", resp.String())
-	result := resp.String()
+	var result LoginResult
+	if err := s.client.Decode(resp, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
 
-	if err != nil {
-		return &common.Response{
-			Code:    500,
-			Message: "请求有误",
-			Data:    result,
-		}, err
+// loginSession 会话登录回调，使用Login传入的账号密码登录上游，响应中的Cookie由会话保存
+func (s *ExampleService) loginSession(ctx context.Context, session *httpclient.Session) error {
+	params, _ := ctx.Value(credentialsKey{}).(map[string]string)
+	if params == nil {
+		return fmt.Errorf("apiServer: 账号 %s 缺少上游登录凭据", session.Account)
 	}
 
-	// 返回成功信息
-	return &common.Response{
-		Code:    200,
-		Message: "请求成功",
-		Data:    result,
-	}, nil
+	resp, err := session.RWithContext(ctx).
+		SetHeader("Content-Type", "application/x-www-form-urlencoded").
+		SetFormData(map[string]string{
+			"uid":      session.Account,
+			"username": params["username"],
+			"password": params["password"],
+		}).
+		Post(sessionLoginPath)
+	return s.client.Check(resp, err)
+}
+
+// isAuthFailure 上游会话失效时返回401；403表示账号无权限，重新登录无法恢复，不触发重试
+func isAuthFailure(resp *req.Response) bool {
+	return resp != nil && resp.Response != nil && resp.StatusCode == http.StatusUnauthorized
 }
@@ -0,0 +1,54 @@
+package apiServer
+
+import (
+	"FastGin/pkg/httpclient"
+	"FastGin/pkg/testutil"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	testutil.Main(m)
+}
+
+// newReplayService 创建回放 testdata/cassettes/lkcoffee.json 的示例服务
+func newReplayService(t *testing.T) *ExampleService {
+	t.Helper()
+	client, err := httpclient.New(ExampleUpstream, testutil.ReplayUpstream("testdata/cassettes/lkcoffee.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return NewExampleService(client)
+}
+
+func TestExampleServiceLogin(t *testing.T) {
+	s := newReplayService(t)
+
+	result, err := s.Login(context.Background(), map[string]string{"uid": "alice", "username": "alice", "password": "secret123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 || result.Status != "SUCCESS" {
+		t.Fatalf("result %+v", result)
+	}
+	if !strings.Contains(string(result.Content), `"orderId":"test-order-0001"`) {
+		t.Fatalf("content %s", result.Content)
+	}
+}
+
+func TestExampleServiceLoginUnmatched(t *testing.T) {
+	s := newReplayService(t)
+
+	// 录制文件中没有该账号的请求，回放模式直接失败而不会访问网络
+	_, err := s.Login(context.Background(), map[string]string{"uid": "mallory", "username": "mallory", "password": "secret123"})
+	var upstreamErr *httpclient.UpstreamError
+	if !errors.As(err, &upstreamErr) || upstreamErr.Kind != httpclient.KindTransport {
+		t.Fatalf("err %v, want transport error", err)
+	}
+	if !strings.Contains(err.Error(), "没有匹配的请求") {
+		t.Fatalf("err %v", err)
+	}
+}
@@ -2,16 +2,30 @@ package main
 
 import (
 	"FastGin/core"
+	"FastGin/pkg/auth"
 	"FastGin/pkg/config"
+	"FastGin/pkg/health"
+	"FastGin/pkg/httpclient"
 	"FastGin/pkg/logg"
+	"FastGin/pkg/metrics"
+	"FastGin/pkg/rbac"
+	"FastGin/pkg/tracing"
+	"FastGin/pkg/webhook"
 	"FastGin/router"
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	//Todo: service里面的login错误处理   data的值应该改为nil还是*result 等待实践后做具体修改
 	//初始化日志系统
 	//使用默认配置
 	Logconfig := logg.DefaultConfig()
@@ -31,14 +45,183 @@ func main() {
 
 	// 设置 Gin 的运行模式
 	gin.SetMode(cfg.Server.Mode)
+	// 初始化链路追踪，需在创建上游客户端和路由之前
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing, nil)
+	if err != nil {
+		logg.Error("初始化链路追踪失败:", err)
+		panic(err)
+	}
+	// 初始化上游HTTP客户端，服务退出时关闭连接
+	clients, err := httpclient.NewManager(cfg)
+	if err != nil {
+		logg.Error("初始化上游客户端失败:", err)
+		panic(err)
+	}
+	defer clients.Close()
+	// 初始化JWT认证，吊销存储与上游客户端共用Redis连接
+	revocations, err := auth.NewRevocationStore(cfg.Auth.Revocation, clients.Redis())
+	if err != nil {
+		logg.Error("初始化令牌吊销存储失败:", err)
+		panic(err)
+	}
+	authenticator, err := auth.New(cfg.Auth, revocations)
+	if err != nil {
+		logg.Error("初始化认证失败:", err)
+		panic(err)
+	}
+	// 加载本地登录账号，未配置账号时登录接口拒绝全部请求
+	users, err := auth.NewUsers(cfg.Auth.Users)
+	if err != nil {
+		logg.Error("加载登录账号失败:", err)
+		panic(err)
+	}
+	if users.Len() == 0 {
+		logg.Warn("未配置 auth.users，登录接口不会签发令牌")
+	}
+	// 初始化登录失败保护，失败记录与上游客户端共用Redis连接
+	attempts, err := auth.NewAttemptStore(cfg.Auth.LoginGuard.Store, clients.Redis())
+	if err != nil {
+		logg.Error("初始化登录失败记录存储失败:", err)
+		panic(err)
+	}
+	guard := auth.NewLoginGuard(cfg.Auth.LoginGuard, attempts)
+	// 初始化API密钥认证，预置密钥在此时写入存储
+	keyStore, err := auth.NewKeyStore(cfg.Auth.APIKeys.Store, clients.Redis())
+	if err != nil {
+		logg.Error("初始化API密钥存储失败:", err)
+		panic(err)
+	}
+	apiKeys, err := auth.NewAPIKeys(cfg.Auth.APIKeys, keyStore)
+	if err != nil {
+		logg.Error("初始化API密钥失败:", err)
+		panic(err)
+	}
+	// 初始化合作方回调签名验证，随机串存储与上游客户端共用Redis连接
+	nonces, err := webhook.NewNonceStore(cfg.Webhooks.NonceStore, clients.Redis())
+	if err != nil {
+		logg.Error("初始化回调随机串存储失败:", err)
+		panic(err)
+	}
+	webhooks, err := webhook.NewVerifier(cfg.Webhooks, nonces)
+	if err != nil {
+		logg.Error("初始化回调签名验证失败:", err)
+		panic(err)
+	}
+	// 加载角色和权限，未授予的权限一律拒绝
+	if err := rbac.Init(context.Background(), rbac.FromConfig(cfg.RBAC)); err != nil {
+		logg.Error("加载访问控制策略失败:", err)
+		panic(err)
+	}
+	// 注册健康检查
+	health.Init(cfg.Health, cfg.Server.Mode)
+	if err := registerHealthChecks(cfg, clients, Logconfig.LogDir); err != nil {
+		logg.Error("注册健康检查失败:", err)
+		panic(err)
+	}
 	// 初始化路由
-	r := router.InitRouter()
+	r := router.InitRouter(cfg, clients, authenticator, users, apiKeys, webhooks, guard)
 	// 启动服务器
 	addr := fmt.Sprintf(":%d", cfg.Server.Port)
 	logg.Info("服务器启动在端口", addr)
 
-	if err := r.Run(addr); err != nil {
-		logg.Error("服务器启动失败:", err)
-		panic(err)
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: r,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logg.Error("服务器启动失败:", err)
+			panic(err)
+		}
+	}()
+
+	// 指标配置了独立监听地址时单独启动，避免暴露在业务端口上
+	var metricsSrv *http.Server
+	if cfg.Metrics.Enabled && cfg.Metrics.Addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle(cfg.Metrics.ScrapePath(), metrics.Handler())
+		metricsSrv = &http.Server{
+			Addr:    cfg.Metrics.Addr,
+			Handler: mux,
+		}
+		logg.Info("指标服务启动在", cfg.Metrics.Addr)
+		go func() {
+			if err := metricsSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logg.Error("指标服务启动失败:", err)
+				panic(err)
+			}
+		}()
+	}
+
+	// 等待退出信号后优雅关闭
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	logg.Info("收到退出信号，开始关闭服务器")
+	// 先让就绪检查失败，等负载均衡摘除实例后再停止接收请求
+	health.SetShuttingDown()
+	if cfg.Health.ShutdownDelay > 0 {
+		time.Sleep(cfg.Health.ShutdownDelay)
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logg.Error("服务器关闭失败:", err)
+	}
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(shutdownCtx); err != nil {
+			logg.Error("指标服务关闭失败:", err)
+		}
+	}
+	// 导出剩余的span
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logg.Error("链路追踪关闭失败:", err)
+	}
+	logg.Info("服务器已关闭")
+}
+
+// registerHealthChecks 注册内置的依赖检查：Redis和 critical_upstreams 中的上游为关键检查，
+// 日志目录可写、磁盘剩余空间和其余上游为非关键检查
+func registerHealthChecks(cfg *config.Config, clients *httpclient.Manager, logDir string) error {
+	critical := make(map[string]bool, len(cfg.Health.CriticalUpstreams))
+	for _, name := range cfg.Health.CriticalUpstreams {
+		if _, ok := cfg.Upstreams[name]; !ok {
+			return fmt.Errorf("health: critical_upstreams 中的上游 %s 未配置", name)
+		}
+		critical[name] = true
+	}
+
+	checks := []health.Check{
+		{Name: "log_writer", Fn: health.LogWriter(logDir)},
+	}
+	if rdb := clients.Redis(); rdb != nil {
+		checks = append(checks, health.Check{
+			Name:     "redis",
+			Critical: true,
+			Fn:       func(ctx context.Context) error { return rdb.Ping(ctx).Err() },
+		})
+	}
+	if cfg.Health.MinFreeDiskMB > 0 {
+		checks = append(checks, health.Check{Name: "disk", Fn: health.DiskSpace(logDir, cfg.Health.MinFreeDiskMB)})
+	}
+	names := make([]string, 0, len(cfg.Upstreams))
+	for name := range cfg.Upstreams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		checks = append(checks, health.Check{
+			Name:     "upstream:" + name,
+			Critical: critical[name],
+			Fn:       health.Upstream(cfg.Upstreams[name].BaseURL),
+		})
+	}
+
+	for _, c := range checks {
+		if err := health.Register(c); err != nil {
+			return err
+		}
 	}
+	return nil
 }
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"FastGin/pkg/auth"
+	"FastGin/pkg/errcode"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader 传递API密钥的请求头，也可以使用 Authorization: ApiKey <key>
+const APIKeyHeader = "X-API-Key"
+
+// APIKey API密钥认证中间件，用于只允许服务间调用的路由组
+// 验证通过后将密钥记录写入上下文，可通过auth.APIKeyFrom读取，认证主体为 key:<id>
+func APIKey(keys *auth.APIKeys) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plain, ok := apiKeyToken(c)
+		if !ok {
+			errcode.SendError(c, errcode.Unauthorized)
+			return
+		}
+		if authenticateAPIKey(c, keys, plain) {
+			c.Next()
+		}
+	}
+}
+
+// AuthOrAPIKey 同时接受用户JWT和API密钥，请求携带API密钥时按API密钥认证，否则按JWT认证
+func AuthOrAPIKey(a *auth.Authenticator, keys *auth.APIKeys) gin.HandlerFunc {
+	jwtAuth := Auth(a)
+
+	return func(c *gin.Context) {
+		plain, ok := apiKeyToken(c)
+		if !ok {
+			jwtAuth(c)
+			return
+		}
+		if authenticateAPIKey(c, keys, plain) {
+			c.Next()
+		}
+	}
+}
+
+// authenticateAPIKey 验证API密钥并写入上下文，失败时中止请求并返回false
+func authenticateAPIKey(c *gin.Context, keys *auth.APIKeys, plain string) bool {
+	key, err := keys.Authenticate(c.Request.Context(), plain, c.Request.Method, c.Request.URL.Path, c.ClientIP())
+	if err != nil {
+		errcode.SendError(c, err)
+		return false
+	}
+
+	c.Set(auth.APIKeyContextKey, key)
+	c.Set(auth.PrincipalKey, auth.APIKeyPrincipal(key.ID))
+	return true
+}
+
+// apiKeyToken 读取X-API-Key请求头或 Authorization: ApiKey <key>
+func apiKeyToken(c *gin.Context) (string, bool) {
+	if key := strings.TrimSpace(c.GetHeader(APIKeyHeader)); key != "" {
+		return key, true
+	}
+	scheme, key, found := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "ApiKey") {
+		return "", false
+	}
+	key = strings.TrimSpace(key)
+	return key, key != ""
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"FastGin/pkg/auth"
+	"FastGin/pkg/errcode"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Auth JWT认证中间件，用于需要登录的路由组
+// 从Authorization: Bearer <token>读取访问令牌，验证签名和吊销状态后将声明写入上下文，可通过auth.ClaimsFrom读取
+func Auth(a *auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c)
+		if !ok {
+			errcode.SendError(c, errcode.Unauthorized)
+			return
+		}
+
+		claims, err := a.Verify(c.Request.Context(), token, auth.TokenAccess)
+		if err != nil {
+			errcode.SendError(c, err)
+			return
+		}
+
+		c.Set(auth.ContextKey, claims)
+		c.Set(auth.PrincipalKey, auth.UserPrincipal(claims.Subject))
+		c.Next()
+	}
+}
+
+// bearerToken 读取Authorization请求头中的Bearer令牌
+func bearerToken(c *gin.Context) (string, bool) {
+	scheme, token, found := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
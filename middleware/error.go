@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"FastGin/pkg/errcode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorHandler 错误处理中间件
+// 处理结束后若尚未输出响应且c.Errors不为空，则将最后一个错误转换为统一响应
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Writer.Written() || len(c.Errors) == 0 {
+			return
+		}
+
+		errcode.SendError(c, c.Errors.Last().Err)
+	}
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"FastGin/pkg/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// I18n 语言协商中间件
+// 根据Accept-Language请求头确定响应语言，写入上下文并通过Content-Language响应头返回
+func I18n() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := i18n.Negotiate(c.GetHeader("Accept-Language"))
+		c.Set(i18n.ContextKey, locale)
+		c.Header("Content-Language", locale)
+
+		c.Next()
+	}
+}
@@ -1,37 +1,76 @@
 package middleware
 
 import (
+	"FastGin/pkg/auth"
 	"FastGin/pkg/logg"
+	"FastGin/pkg/response"
 	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// omittedBody 包含凭据的响应在日志中的占位内容
+const omittedBody = "[omitted]"
+
+// maskedValue 日志中凭据的占位值
+const maskedValue = "******"
+
+// maxLoggedBody 日志中间件缓存的请求体最大字节数，超出时不记录请求体，其余部分由处理器直接从连接读取
+// 处理器自己的大小限制（如http.MaxBytesReader）因此仍然有效
+const maxLoggedBody = 64 << 10
+
+// sensitiveFields 请求体中不记录取值的字段，不区分大小写
+var sensitiveFields = map[string]bool{
+	"password":      true,
+	"old_password":  true,
+	"new_password":  true,
+	"secret":        true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+}
+
+var (
+	errorLogOnce sync.Once
+	errorLogFile *os.File
+)
+
+// openErrorLog 打开或创建错误日志文件，日志与恢复中间件共用同一个文件句柄
+func openErrorLog() *os.File {
+	errorLogOnce.Do(func() {
+		// 确保错误日志目录存在
+		logDir := "logs"
+		if err := os.MkdirAll(logDir, 0755); err != nil {
+			logg.Error("创建日志目录失败", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+
+		errorLogPath := filepath.Join(logDir, "error.log")
+		file, err := os.OpenFile(errorLogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+		if err != nil {
+			logg.Error("打开错误日志文件失败", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		errorLogFile = file
+	})
+	return errorLogFile
+}
+
 // LoggerMiddleware 日志中间件
 func LoggerMiddleware() gin.HandlerFunc {
-	// 确保错误日志目录存在
-	logDir := "logs"
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		logg.Error("创建日志目录失败", map[string]interface{}{
-			"error": err.Error(),
-		})
-	}
-
-	// 打开或创建错误日志文件
-	errorLogPath := filepath.Join(logDir, "error.log")
-	errorLogFile, err := os.OpenFile(errorLogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		logg.Error("打开错误日志文件失败", map[string]interface{}{
-			"error": err.Error(),
-		})
-	}
+	errorLogFile := openErrorLog()
 
 	return func(c *gin.Context) {
 		// 获取请求ID和追踪ID
@@ -44,24 +83,40 @@ func LoggerMiddleware() gin.HandlerFunc {
 			traceID = requestID
 		}
 
+		// 启用链路追踪时记录服务端span的ID
+		spanID := c.GetString("SpanID")
+
 		// 记录请求开始
-		logg.Info("开始处理请求", map[string]interface{}{
+		startInfo := map[string]interface{}{
 			"request_id": requestID,
 			"trace_id":   traceID,
 			"method":     c.Request.Method,
 			"path":       c.Request.URL.Path,
 			"client_ip":  c.ClientIP(),
 			"user_agent": c.Request.UserAgent(),
-		})
+		}
+		if spanID != "" {
+			startInfo["span_id"] = spanID
+		}
+		logg.Info("开始处理请求", startInfo)
 
 		// 开始时间
 		startTime := time.Now()
 
-		// 记录请求体
+		// 记录请求体，密码和令牌等字段脱敏后写入日志
+		// 最多缓存maxLoggedBody字节，过长的请求体截断后无法脱敏，只记录占位内容
 		var requestBody []byte
 		if c.Request.Body != nil {
-			requestBody, _ = io.ReadAll(c.Request.Body)
-			c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
+			requestBody, _ = io.ReadAll(io.LimitReader(c.Request.Body, maxLoggedBody+1))
+			c.Request.Body = replayBody{
+				Reader: io.MultiReader(bytes.NewReader(requestBody), c.Request.Body),
+				Closer: c.Request.Body,
+			}
+			if len(requestBody) > maxLoggedBody {
+				requestBody = []byte(fmt.Sprintf("[omitted: larger than %d bytes]", maxLoggedBody))
+			} else {
+				requestBody = maskBody(c.ContentType(), requestBody)
+			}
 		}
 
 		// 使用自定义ResponseWriter记录响应
@@ -75,6 +130,12 @@ func LoggerMiddleware() gin.HandlerFunc {
 		endTime := time.Now()
 		latencyTime := endTime.Sub(startTime)
 
+		// 包含凭据的响应不记录响应体
+		responseBody := blw.body.Bytes()
+		if c.GetBool(response.SensitiveKey) {
+			responseBody = []byte(omittedBody)
+		}
+
 		// 获取请求信息
 		reqInfo := map[string]interface{}{
 			"status_code":   c.Writer.Status(),
@@ -84,13 +145,17 @@ func LoggerMiddleware() gin.HandlerFunc {
 			"uri":           c.Request.RequestURI,
 			"path":          c.Request.URL.Path,
 			"query_params":  c.Request.URL.RawQuery,
-			"headers":       c.Request.Header,
+			"headers":       maskCredentials(c.Request.Header),
 			"request_body":  string(requestBody),
-			"response_body": blw.body.String(),
+			"response_body": string(responseBody),
 			"error":         c.Errors.String(),
 			"user_agent":    c.Request.UserAgent(),
 			"request_id":    requestID,
 			"trace_id":      traceID,
+			"principal":     auth.Principal(c),
+		}
+		if spanID != "" {
+			reqInfo["span_id"] = spanID
 		}
 
 		// 根据状态码决定日志级别
@@ -99,11 +164,11 @@ func LoggerMiddleware() gin.HandlerFunc {
 		case statusCode >= 500:
 			logg.Error("请求处理失败", reqInfo)
 			// 记录详细错误信息到错误日志文件
-			writeErrorLog(errorLogFile, c, requestBody, blw.body.Bytes(), fmt.Sprint(requestID), fmt.Sprint(traceID), latencyTime)
+			writeErrorLog(errorLogFile, c, requestBody, responseBody, fmt.Sprint(requestID), fmt.Sprint(traceID), latencyTime)
 		case statusCode >= 400:
 			logg.Warn("请求参数错误", reqInfo)
 			// 记录详细错误信息到错误日志文件
-			writeErrorLog(errorLogFile, c, requestBody, blw.body.Bytes(), fmt.Sprint(requestID), fmt.Sprint(traceID), latencyTime)
+			writeErrorLog(errorLogFile, c, requestBody, responseBody, fmt.Sprint(requestID), fmt.Sprint(traceID), latencyTime)
 		default:
 			logg.Info("请求处理完成", reqInfo)
 		}
@@ -149,10 +214,21 @@ func writeErrorLog(file *os.File, c *gin.Context, requestBody, responseBody []by
 		"e":         strings.Join(c.Errors.Errors(), "; "),         // 错误信息
 	}
 
+	// 添加span ID，启用链路追踪时存在
+	if spanID := c.GetString("SpanID"); spanID != "" {
+		errorLog["sp"] = spanID
+	}
+
+	// 添加认证主体，API密钥认证时为 key:<id>
+	if principal := auth.Principal(c); principal != "" {
+		errorLog["pr"] = principal
+	}
+
 	// 添加请求头（仅添加关键header）
 	headers := make(map[string]string)
+	masked := maskCredentials(c.Request.Header)
 	for _, key := range []string{"Content-Type", "Authorization", "X-Request-ID", "X-Trace-ID"} {
-		if value := c.GetHeader(key); value != "" {
+		if value := masked.Get(key); value != "" {
 			headers[key] = value
 		}
 	}
@@ -177,6 +253,88 @@ func writeErrorLog(file *os.File, c *gin.Context, requestBody, responseBody []by
 	file.WriteString(fmt.Sprintf("%s\n", string(logJSON)))
 }
 
+// maskCredentials 隐藏请求头中的API密钥和令牌，只保留 Authorization 的认证方案，如 "Bearer ******"
+func maskCredentials(h http.Header) http.Header {
+	if h.Get(APIKeyHeader) == "" && h.Get("Authorization") == "" {
+		return h
+	}
+	cp := h.Clone()
+	if cp.Get(APIKeyHeader) != "" {
+		cp.Set(APIKeyHeader, maskedValue)
+	}
+	if authorization := cp.Get("Authorization"); authorization != "" {
+		masked := maskedValue
+		if scheme, _, ok := strings.Cut(authorization, " "); ok {
+			masked = scheme + " " + maskedValue
+		}
+		cp.Set("Authorization", masked)
+	}
+	return cp
+}
+
+// maskBody 隐藏请求体中的密码和令牌字段，支持表单和JSON，其他格式原样返回
+func maskBody(contentType string, body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+
+	if contentType == "application/x-www-form-urlencoded" {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return body
+		}
+		changed := false
+		for name := range values {
+			if sensitiveFields[strings.ToLower(name)] {
+				values[name] = []string{maskedValue}
+				changed = true
+			}
+		}
+		if !changed {
+			return body
+		}
+		return []byte(values.Encode())
+	}
+
+	// 与参数绑定一致，非表单请求体按JSON处理
+	var obj interface{}
+	if err := json.Unmarshal(body, &obj); err != nil || !maskJSON(obj) {
+		return body
+	}
+	masked, err := json.Marshal(obj)
+	if err != nil {
+		return body
+	}
+	return masked
+}
+
+// maskJSON 递归隐藏JSON中的敏感字段，返回是否有字段被隐藏
+func maskJSON(v interface{}) bool {
+	changed := false
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for name, item := range val {
+			if sensitiveFields[strings.ToLower(name)] {
+				val[name] = maskedValue
+				changed = true
+				continue
+			}
+			changed = maskJSON(item) || changed
+		}
+	case []interface{}:
+		for _, item := range val {
+			changed = maskJSON(item) || changed
+		}
+	}
+	return changed
+}
+
+// replayBody 先返回日志中间件已读取的部分，再继续读取原始请求体
+type replayBody struct {
+	io.Reader
+	io.Closer
+}
+
 // bodyLogWriter 用于记录响应体
 type bodyLogWriter struct {
 	gin.ResponseWriter
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"FastGin/pkg/metrics"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute 未匹配到路由的请求使用的route标签，避免任意路径造成时间序列膨胀
+const unmatchedRoute = "unmatched"
+
+var (
+	httpRequests = metrics.NewCounterVec("http_requests_total",
+		"按路由、方法和状态码统计的请求数", "method", "route", "status")
+	httpDuration = metrics.NewHistogramVec("http_request_duration_seconds",
+		"按路由、方法和状态码统计的请求耗时", nil, "method", "route", "status")
+	httpInFlight = metrics.NewGaugeVec("http_requests_in_flight",
+		"正在处理的请求数", "method", "route")
+	rateLimitRejected = metrics.NewCounterVec("http_rate_limit_rejected_total",
+		"被限流拒绝的请求数，limiter为global或api_key", "limiter")
+	panicsRecovered = metrics.NewCounter("http_panics_recovered_total",
+		"恢复中间件捕获的panic数，不含客户端断开连接")
+)
+
+// Metrics 请求指标中间件，需放在恢复和限流中间件之前，才能统计到其写入的状态码
+// route标签为路由模板（c.FullPath()），如 /admin/api-keys/:id
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method := metricMethod(c.Request.Method)
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		inFlight := httpInFlight.WithLabelValues(method, route)
+		inFlight.Inc()
+		start := time.Now()
+
+		c.Next()
+
+		inFlight.Dec()
+		status := strconv.Itoa(c.Writer.Status())
+		httpRequests.WithLabelValues(method, route, status).Inc()
+		httpDuration.WithLabelValues(method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// metricMethod 非标准的请求方法统一记为OTHER
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
@@ -1,7 +1,11 @@
 package middleware
 
 import (
-	"net/http"
+	"FastGin/pkg/auth"
+	"FastGin/pkg/config"
+	"FastGin/pkg/errcode"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
@@ -13,12 +17,77 @@ func RateLimit(maxRequests int, perSecond float64) gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 		if !limiter.Allow() {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"message": "请求太频繁，请稍后再试",
-			})
-			c.Abort()
+			rateLimitRejected.WithLabelValues("global").Inc()
+			errcode.SendError(c, errcode.TooManyRequests)
 			return
 		}
 		c.Next()
 	}
 }
+
+// APIKeyRateLimit 按API密钥各自的rate_limit限流，需放在API密钥认证中间件之后
+// 请求未使用API密钥认证或密钥未配置限流时直接放行，全局限流仍由RateLimit负责
+func APIKeyRateLimit() gin.HandlerFunc {
+	limiters := &keyLimiters{limiters: make(map[keyLimit]*rate.Limiter)}
+
+	return func(c *gin.Context) {
+		key, ok := auth.APIKeyFrom(c)
+		if !ok || key.RateLimit.RPS <= 0 {
+			c.Next()
+			return
+		}
+
+		if !limiters.allow(keyLimit{id: key.ID, limit: key.RateLimit}) {
+			rateLimitRejected.WithLabelValues("api_key").Inc()
+			errcode.SendError(c, errcode.TooManyRequests)
+			return
+		}
+		c.Next()
+	}
+}
+
+// keyLimit 限流器的键，预置密钥的限流配置变更后使用新的限流器
+type keyLimit struct {
+	id    string
+	limit config.APIKeyRateLimit
+}
+
+// keyLimiters 各API密钥的限流器
+// 令牌已补满的限流器与新建的没有区别，定期清理，吊销或不再使用的密钥不会一直占用内存
+type keyLimiters struct {
+	mu        sync.Mutex
+	limiters  map[keyLimit]*rate.Limiter
+	lastSweep time.Time
+}
+
+// allow 判断密钥是否还有令牌，限流器不存在时创建
+func (l *keyLimiters) allow(k keyLimit) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+	limiter, ok := l.limiters[k]
+	if !ok {
+		burst := k.limit.Burst
+		if burst <= 0 {
+			burst = max(int(k.limit.RPS), 1)
+		}
+		limiter = rate.NewLimiter(rate.Limit(k.limit.RPS), burst)
+		l.limiters[k] = limiter
+	}
+	return limiter.AllowN(now, 1)
+}
+
+// sweep 每分钟最多清理一次令牌已补满的限流器，调用方需持有锁
+func (l *keyLimiters) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for k, limiter := range l.limiters {
+		if limiter.TokensAt(now) >= float64(limiter.Burst()) {
+			delete(l.limiters, k)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"FastGin/pkg/config"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestKeyLimitersSweep(t *testing.T) {
+	l := &keyLimiters{limiters: make(map[keyLimit]*rate.Limiter), lastSweep: time.Now()}
+	fast := keyLimit{id: "fast", limit: config.APIKeyRateLimit{RPS: 1000, Burst: 1}}
+	slow := keyLimit{id: "slow", limit: config.APIKeyRateLimit{RPS: 0.001, Burst: 1}}
+
+	if !l.allow(fast) || !l.allow(slow) {
+		t.Fatal("first request should be allowed")
+	}
+	if l.allow(slow) {
+		t.Fatal("slow key should be limited")
+	}
+
+	// 同一密钥的限流配置变更后使用新的限流器
+	if !l.allow(keyLimit{id: "slow", limit: config.APIKeyRateLimit{RPS: 10}}) {
+		t.Fatal("changed rate limit should start a new limiter")
+	}
+
+	// fast的令牌已补满，清理后与新建的限流器相同；slow仍在限流中，需要保留
+	time.Sleep(5 * time.Millisecond)
+	l.lastSweep = time.Time{}
+	l.allow(keyLimit{id: "other", limit: config.APIKeyRateLimit{RPS: 1}})
+	if _, ok := l.limiters[fast]; ok {
+		t.Error("refilled limiter was not swept")
+	}
+	if _, ok := l.limiters[slow]; !ok {
+		t.Fatal("limiter with pending tokens was swept")
+	}
+	if l.allow(slow) {
+		t.Fatal("slow key should still be limited after sweep")
+	}
+}
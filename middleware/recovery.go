@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"FastGin/pkg/errcode"
+	"FastGin/pkg/logg"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery 恢复中间件
+// 捕获panic并通过logg记录堆栈和请求上下文，同时写入错误日志文件，返回统一的服务内部错误响应
+// 客户端断开连接（broken pipe / connection reset）不视为服务端故障
+func Recovery() gin.HandlerFunc {
+	errorLogFile := openErrorLog()
+
+	return func(c *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+
+			requestID := c.GetString("RequestID")
+			traceID := c.GetString("TraceID")
+			stack := string(debug.Stack())
+
+			if isBrokenPipe(rec) {
+				logg.Warn("客户端连接已断开", map[string]interface{}{
+					"request_id": requestID,
+					"trace_id":   traceID,
+					"method":     c.Request.Method,
+					"path":       c.Request.URL.Path,
+					"error":      fmt.Sprint(rec),
+				})
+				if err, ok := rec.(error); ok {
+					c.Error(err)
+				}
+				c.Abort()
+				return
+			}
+
+			panicsRecovered.Inc()
+			logg.Error("请求处理发生panic", map[string]interface{}{
+				"request_id": requestID,
+				"trace_id":   traceID,
+				"method":     c.Request.Method,
+				"path":       c.Request.URL.Path,
+				"client_ip":  c.ClientIP(),
+				"error":      fmt.Sprint(rec),
+				"stack":      stack,
+			})
+			writePanicLog(errorLogFile, c, rec, stack, requestID, traceID)
+
+			c.Error(fmt.Errorf("panic: %v", rec))
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			errcode.SendError(c, errcode.InternalServerError)
+		}()
+
+		c.Next()
+	}
+}
+
+// isBrokenPipe 判断panic是否由客户端断开连接引起
+func isBrokenPipe(rec interface{}) bool {
+	err, ok := rec.(error)
+	if !ok {
+		return false
+	}
+
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+
+	var sysErr *os.SyscallError
+	if errors.As(opErr, &sysErr) {
+		msg := strings.ToLower(sysErr.Error())
+		return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+	}
+	return false
+}
+
+// writePanicLog 将panic信息以单行JSON写入错误日志文件
+func writePanicLog(file *os.File, c *gin.Context, rec interface{}, stack, requestID, traceID string) {
+	errorLog := map[string]interface{}{
+		"t":         time.Now().Format("2006-01-02T15:04:05.000Z"), // 时间戳
+		"requestID": requestID,                                     // 请求ID
+		"traceID":   traceID,                                       // 追踪ID
+		"m":         c.Request.Method,                              // 请求方法
+		"p":         c.Request.URL.Path,                            // 请求路径
+		"q":         c.Request.URL.RawQuery,                        // 查询参数
+		"ip":        c.ClientIP(),                                  // 客户端IP
+		"panic":     fmt.Sprint(rec),                               // panic值
+		"stack":     stack,                                         // 调用堆栈
+	}
+
+	logJSON, _ := json.Marshal(errorLog)
+	file.WriteString(fmt.Sprintf("%s\n", string(logJSON)))
+}
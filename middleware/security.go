@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"FastGin/pkg/config"
+	"crypto/rand"
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CSPNonceKey 当前请求的CSP随机数在gin.Context中的键
+const CSPNonceKey = "CSPNonce"
+
+// securityHeadersKey 当前请求生效的安全响应头配置在gin.Context中的键
+const securityHeadersKey = "SecurityHeaders"
+
+// nonceBytes CSP随机数的字节数
+const nonceBytes = 16
+
+// SecurityHeaders 安全响应头中间件，在处理器执行前写入响应头，路由可通过 SecurityOverride 覆盖
+func SecurityHeaders(cfg config.SecurityHeadersConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		applySecurityHeaders(c, cfg)
+		c.Next()
+	}
+}
+
+// SecurityOverride 路由级覆盖安全响应头，需放在 SecurityHeaders 之后
+// override 修改的是当前请求生效配置的副本，不影响其他路由
+//
+//	r.GET("/embed", middleware.SecurityOverride(func(h *config.SecurityHeadersConfig) {
+//		h.FrameOptions = ""
+//		h.CSP.ReportOnly = true
+//	}), handler)
+func SecurityOverride(override func(h *config.SecurityHeadersConfig)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var cfg config.SecurityHeadersConfig
+		if v, ok := c.Get(securityHeadersKey); ok {
+			cfg = v.(config.SecurityHeadersConfig)
+		}
+		override(&cfg)
+		applySecurityHeaders(c, cfg)
+		c.Next()
+	}
+}
+
+// CSPNonce 当前请求的CSP随机数，供模板写入 <script nonce="..."> ，策略未使用 {nonce} 时返回空串
+//
+//	c.HTML(http.StatusOK, "index.tmpl", gin.H{"nonce": middleware.CSPNonce(c)})
+func CSPNonce(c *gin.Context) string {
+	return c.GetString(CSPNonceKey)
+}
+
+// applySecurityHeaders 按配置写入或删除安全响应头，并记录生效的配置供路由覆盖
+func applySecurityHeaders(c *gin.Context, cfg config.SecurityHeadersConfig) {
+	c.Set(securityHeadersKey, cfg)
+
+	hsts := ""
+	if cfg.HSTS.MaxAge > 0 {
+		hsts = "max-age=" + strconv.FormatInt(int64(cfg.HSTS.MaxAge.Seconds()), 10)
+		if cfg.HSTS.IncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if cfg.HSTS.Preload {
+			hsts += "; preload"
+		}
+	}
+	nosniff := ""
+	if cfg.ContentTypeNosniff {
+		nosniff = "nosniff"
+	}
+	c.Header("Strict-Transport-Security", hsts)
+	c.Header("X-Content-Type-Options", nosniff)
+	c.Header("X-Frame-Options", cfg.FrameOptions)
+	c.Header("Referrer-Policy", cfg.ReferrerPolicy)
+	c.Header("Permissions-Policy", cfg.PermissionsPolicy)
+
+	// 两个CSP响应头同时只发送一个，覆盖时切换模式需删除另一个
+	c.Header("Content-Security-Policy", "")
+	c.Header("Content-Security-Policy-Report-Only", "")
+	c.Header("Reporting-Endpoints", "")
+	if cfg.CSP.Policy == "" {
+		return
+	}
+	policy := cfg.CSP.Policy
+	if strings.Contains(policy, "{nonce}") {
+		policy = strings.ReplaceAll(policy, "{nonce}", cspNonce(c))
+	}
+	if cfg.CSP.ReportURI != "" {
+		policy += "; report-uri " + cfg.CSP.ReportURI + "; report-to csp"
+		c.Header("Reporting-Endpoints", `csp="`+cfg.CSP.ReportURI+`"`)
+	}
+	if cfg.CSP.ReportOnly {
+		c.Header("Content-Security-Policy-Report-Only", policy)
+	} else {
+		c.Header("Content-Security-Policy", policy)
+	}
+}
+
+// cspNonce 读取或生成当前请求的CSP随机数，同一请求多次覆盖时保持不变
+func cspNonce(c *gin.Context) string {
+	if nonce := c.GetString(CSPNonceKey); nonce != "" {
+		return nonce
+	}
+	b := make([]byte, nonceBytes)
+	// crypto/rand.Read 在支持的平台上不会失败
+	_, _ = rand.Read(b)
+	nonce := base64.StdEncoding.EncodeToString(b)
+	c.Set(CSPNonceKey, nonce)
+	return nonce
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"FastGin/pkg/tracing"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing 链路追踪中间件，为每个请求创建以 "方法 路由模板" 命名的服务端span，需放在RequestID之后、日志中间件之前
+// 请求携带W3C traceparent时作为其子span；span有效时以其trace_id替换TraceID，日志和X-Trace-ID随之使用
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		method := metricMethod(c.Request.Method)
+		route := c.FullPath()
+		name := method
+		attrs := []attribute.KeyValue{
+			semconv.HTTPRequestMethodKey.String(method),
+			semconv.URLPath(c.Request.URL.Path),
+			semconv.ClientAddress(c.ClientIP()),
+			semconv.UserAgentOriginal(c.Request.UserAgent()),
+			attribute.String("request_id", c.GetString("RequestID")),
+		}
+		if route != "" {
+			name += " " + route
+			attrs = append(attrs, semconv.HTTPRoute(route))
+		}
+
+		ctx, span := tracing.Tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		if sc := span.SpanContext(); sc.IsValid() {
+			c.Set("TraceID", sc.TraceID().String())
+			c.Set("SpanID", sc.SpanID().String())
+			c.Header("X-Trace-ID", sc.TraceID().String())
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		for _, e := range c.Errors {
+			span.RecordError(e.Err)
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
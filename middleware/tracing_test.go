@@ -0,0 +1,242 @@
+package middleware
+
+import (
+	"FastGin/pkg/config"
+	"FastGin/pkg/httpclient"
+	"FastGin/pkg/testutil"
+	"FastGin/pkg/tracing"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMain(m *testing.M) {
+	testutil.Main(m)
+}
+
+// initTracing 使用内存导出器初始化全局TracerProvider，测试结束后恢复原来的Provider
+func initTracing(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	prev := otel.GetTracerProvider()
+	shutdown, err := tracing.Init(context.Background(), config.TracingConfig{Enabled: true}, exporter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		shutdown(context.Background())
+		otel.SetTracerProvider(prev)
+	})
+	return exporter
+}
+
+// endedSpans 导出已结束的span，内存导出器在Shutdown时会清空，因此通过ForceFlush读取
+func endedSpans(t *testing.T, exporter *tracetest.InMemoryExporter) tracetest.SpanStubs {
+	t.Helper()
+	if err := otel.GetTracerProvider().(*sdktrace.TracerProvider).ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return exporter.GetSpans()
+}
+
+// upstream 记录收到的traceparent，statuses依次作为各次请求的响应状态码，用完后返回200
+type upstream struct {
+	*httptest.Server
+	hits atomic.Int32
+
+	mu           sync.Mutex
+	traceparents []string
+}
+
+func newUpstream(t *testing.T, statuses ...int) *upstream {
+	t.Helper()
+	u := &upstream{}
+	u.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(u.hits.Add(1))
+		u.mu.Lock()
+		u.traceparents = append(u.traceparents, r.Header.Get("traceparent"))
+		u.mu.Unlock()
+		if n <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(u.Close)
+	return u
+}
+
+// newTracedRouter 创建带追踪中间件的路由，/orders/:id 调用client指向的上游
+func newTracedRouter(t *testing.T, client *httpclient.Client) *gin.Engine {
+	t.Helper()
+	r := gin.New()
+	r.Use(RequestID(), Tracing())
+	r.GET("/orders/:id", func(c *gin.Context) {
+		if client != nil {
+			client.R().SetContext(c.Request.Context()).Get("/items/" + c.Param("id"))
+		}
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func newUpstreamClient(t *testing.T, baseURL string, retry config.RetryConfig) *httpclient.Client {
+	t.Helper()
+	client, err := httpclient.New("orders", config.UpstreamConfig{BaseURL: baseURL, Retry: retry})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+// spansByKind 按span类型分组
+func spansByKind(spans tracetest.SpanStubs) map[trace.SpanKind][]tracetest.SpanStub {
+	grouped := make(map[trace.SpanKind][]tracetest.SpanStub)
+	for _, s := range spans {
+		grouped[s.SpanKind] = append(grouped[s.SpanKind], s)
+	}
+	return grouped
+}
+
+func attrValue(attrs []attribute.KeyValue, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTracingServerSpan(t *testing.T) {
+	exporter := initTracing(t)
+	r := newTracedRouter(t, nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/42", nil))
+
+	spans := endedSpans(t, exporter)
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "GET /orders/:id" || span.SpanKind != trace.SpanKindServer {
+		t.Fatalf("span %q kind %v", span.Name, span.SpanKind)
+	}
+	if route, _ := attrValue(span.Attributes, semconv.HTTPRouteKey); route.AsString() != "/orders/:id" {
+		t.Errorf("http.route %q", route.AsString())
+	}
+	if status, _ := attrValue(span.Attributes, semconv.HTTPResponseStatusCodeKey); status.AsInt64() != http.StatusOK {
+		t.Errorf("status %d", status.AsInt64())
+	}
+	if got := w.Header().Get("X-Trace-ID"); got != span.SpanContext.TraceID().String() {
+		t.Errorf("X-Trace-ID %q, want %s", got, span.SpanContext.TraceID())
+	}
+}
+
+func TestTracingClientSpan(t *testing.T) {
+	exporter := initTracing(t)
+	up := newUpstream(t)
+	r := newTracedRouter(t, newUpstreamClient(t, up.URL, config.RetryConfig{}))
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/42", nil))
+
+	grouped := spansByKind(endedSpans(t, exporter))
+	if len(grouped[trace.SpanKindServer]) != 1 || len(grouped[trace.SpanKindClient]) != 1 {
+		t.Fatalf("spans %v", grouped)
+	}
+	server, client := grouped[trace.SpanKindServer][0], grouped[trace.SpanKindClient][0]
+	if client.Name != "GET orders" {
+		t.Errorf("client span %q", client.Name)
+	}
+	if client.Parent.SpanID() != server.SpanContext.SpanID() || client.SpanContext.TraceID() != server.SpanContext.TraceID() {
+		t.Errorf("client span is not a child of the server span")
+	}
+
+	// 上游收到的traceparent指向客户端span
+	want := fmt.Sprintf("00-%s-%s-01", client.SpanContext.TraceID(), client.SpanContext.SpanID())
+	if len(up.traceparents) != 1 || up.traceparents[0] != want {
+		t.Errorf("upstream traceparent %q, want %q", up.traceparents, want)
+	}
+}
+
+func TestTracingPropagation(t *testing.T) {
+	exporter := initTracing(t)
+	up := newUpstream(t)
+	r := newTracedRouter(t, newUpstreamClient(t, up.URL, config.RetryConfig{}))
+
+	const traceID, parentID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	req := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	grouped := spansByKind(endedSpans(t, exporter))
+	server := grouped[trace.SpanKindServer][0]
+	if server.SpanContext.TraceID().String() != traceID || server.Parent.SpanID().String() != parentID {
+		t.Fatalf("server span trace %s parent %s", server.SpanContext.TraceID(), server.Parent.SpanID())
+	}
+	if !server.Parent.IsRemote() {
+		t.Error("server span parent is not remote")
+	}
+	if got := w.Header().Get("X-Trace-ID"); got != traceID {
+		t.Errorf("X-Trace-ID %q", got)
+	}
+
+	client := grouped[trace.SpanKindClient][0]
+	want := fmt.Sprintf("00-%s-%s-01", traceID, client.SpanContext.SpanID())
+	if len(up.traceparents) != 1 || up.traceparents[0] != want {
+		t.Errorf("upstream traceparent %q, want %q", up.traceparents, want)
+	}
+}
+
+func TestTracingRetrySpans(t *testing.T) {
+	exporter := initTracing(t)
+	up := newUpstream(t, http.StatusServiceUnavailable)
+	r := newTracedRouter(t, newUpstreamClient(t, up.URL, config.RetryConfig{
+		MaxAttempts:     2,
+		InitialInterval: time.Millisecond,
+		MaxInterval:     time.Millisecond,
+		Statuses:        []int{http.StatusServiceUnavailable},
+	}))
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/42", nil))
+
+	grouped := spansByKind(endedSpans(t, exporter))
+	server, clients := grouped[trace.SpanKindServer][0], grouped[trace.SpanKindClient]
+	if len(clients) != 2 {
+		t.Fatalf("got %d client spans, want one per attempt", len(clients))
+	}
+	// 每次尝试都是服务端span的子span，不会挂在上一次尝试的span下
+	for i, client := range clients {
+		if client.Parent.SpanID() != server.SpanContext.SpanID() {
+			t.Errorf("attempt %d parent %s, want server span %s", i, client.Parent.SpanID(), server.SpanContext.SpanID())
+		}
+		resend, ok := attrValue(client.Attributes, semconv.HTTPRequestResendCountKey)
+		if i == 0 && ok {
+			t.Errorf("first attempt has resend count %d", resend.AsInt64())
+		}
+		if i == 1 && resend.AsInt64() != 1 {
+			t.Errorf("retry resend count %d, want 1", resend.AsInt64())
+		}
+	}
+	for i, tp := range up.traceparents {
+		want := fmt.Sprintf("00-%s-%s-01", clients[i].SpanContext.TraceID(), clients[i].SpanContext.SpanID())
+		if tp != want {
+			t.Errorf("attempt %d traceparent %q, want %q", i, tp, want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"FastGin/pkg/auth"
+	"FastGin/pkg/errcode"
+	"FastGin/pkg/webhook"
+	"bytes"
+	"io"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Webhook 合作方回调签名验证中间件
+// 读取请求体后重新写回，LoggerMiddleware之前或之后注册都不影响处理器读取请求体
+func Webhook(v *webhook.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			body, err = io.ReadAll(c.Request.Body)
+			if err != nil {
+				errcode.SendError(c, errcode.InvalidParams.Wrap(err))
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		partner := c.GetHeader(webhook.HeaderPartner)
+		err := v.Verify(c.Request.Context(), &webhook.Request{
+			Method:    c.Request.Method,
+			URI:       c.Request.URL.RequestURI(),
+			Partner:   partner,
+			Timestamp: c.GetHeader(webhook.HeaderTimestamp),
+			Nonce:     c.GetHeader(webhook.HeaderNonce),
+			Signature: c.GetHeader(webhook.HeaderSignature),
+			Body:      body,
+		})
+		if err != nil {
+			errcode.SendError(c, err)
+			return
+		}
+
+		c.Set(webhook.ContextKey, partner)
+		c.Set(auth.PrincipalKey, auth.PartnerPrincipal(partner))
+		c.Next()
+	}
+}
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"FastGin/pkg/config"
+	"FastGin/pkg/errcode"
+	"FastGin/pkg/testutil"
+	"FastGin/pkg/webhook"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// webhookBody 回调测试使用的请求体
+const webhookBody = `{"event":"order.paid","order_id":"1001"}`
+
+// newWebhookRouter 创建回调路由，处理器原样返回读取到的请求体
+func newWebhookRouter(t *testing.T, loggerFirst bool) *gin.Engine {
+	t.Helper()
+	testutil.InTempDir(t)
+	v, err := webhook.NewVerifier(config.WebhookConfig{
+		Skew:     time.Minute,
+		Partners: []config.PartnerConfig{{ID: "acme", Secrets: []string{"new-secret", "old-secret"}}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	echo := func(c *gin.Context) {
+		body, err := c.GetRawData()
+		if err != nil {
+			t.Error(err)
+		}
+		c.String(http.StatusOK, "%s|%s", webhook.PartnerFrom(c), body)
+	}
+	if loggerFirst {
+		r.Use(LoggerMiddleware(), Webhook(v))
+	} else {
+		r.Use(Webhook(v), LoggerMiddleware())
+	}
+	r.POST("/webhooks/orders", echo)
+	return r
+}
+
+// signedRequest 按webhook.Sign生成带签名的回调请求
+func signedRequest(secret, partner, nonce string, ts time.Time, body string) *http.Request {
+	wr := &webhook.Request{
+		Method:    http.MethodPost,
+		URI:       "/webhooks/orders?source=test",
+		Partner:   partner,
+		Timestamp: strconv.FormatInt(ts.Unix(), 10),
+		Nonce:     nonce,
+		Body:      []byte(body),
+	}
+	req := httptest.NewRequest(wr.Method, wr.URI, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.HeaderPartner, wr.Partner)
+	req.Header.Set(webhook.HeaderTimestamp, wr.Timestamp)
+	req.Header.Set(webhook.HeaderNonce, wr.Nonce)
+	req.Header.Set(webhook.HeaderSignature, "sha256="+webhook.Sign(secret, wr))
+	return req
+}
+
+// serve 发送请求，返回状态码、业务码和响应体
+func serve(r *gin.Engine, req *http.Request) (int, int, string) {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var body struct {
+		Code int `json:"code"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	return w.Code, body.Code, w.Body.String()
+}
+
+func TestWebhookHandlerReadsBody(t *testing.T) {
+	for _, loggerFirst := range []bool{true, false} {
+		t.Run("loggerFirst="+strconv.FormatBool(loggerFirst), func(t *testing.T) {
+			r := newWebhookRouter(t, loggerFirst)
+			status, _, body := serve(r, signedRequest("new-secret", "acme", "n-1", time.Now(), webhookBody))
+			if status != http.StatusOK || body != "acme|"+webhookBody {
+				t.Fatalf("status %d body %q", status, body)
+			}
+		})
+	}
+}
+
+func TestWebhookRejects(t *testing.T) {
+	r := newWebhookRouter(t, true)
+	now := time.Now()
+
+	tamper := signedRequest("new-secret", "acme", "n-tamper", now, webhookBody)
+	tamper.Body = http.NoBody
+
+	tests := []struct {
+		name     string
+		req      *http.Request
+		wantCode int
+	}{
+		{"轮换中的旧密钥", signedRequest("old-secret", "acme", "n-old", now, webhookBody), 0},
+		{"密钥错误", signedRequest("wrong-secret", "acme", "n-wrong", now, webhookBody), errcode.SignatureInvalid.Code},
+		{"未知合作方", signedRequest("new-secret", "mallory", "n-unknown", now, webhookBody), errcode.SignatureInvalid.Code},
+		{"请求体被篡改", tamper, errcode.SignatureInvalid.Code},
+		{"时间戳过早", signedRequest("new-secret", "acme", "n-past", now.Add(-2*time.Minute), webhookBody), errcode.SignatureExpired.Code},
+		{"时间戳过晚", signedRequest("new-secret", "acme", "n-future", now.Add(2*time.Minute), webhookBody), errcode.SignatureExpired.Code},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, code, body := serve(r, tt.req)
+			if tt.wantCode == 0 {
+				if status != http.StatusOK {
+					t.Fatalf("status %d body %s", status, body)
+				}
+				return
+			}
+			if status != http.StatusUnauthorized || code != tt.wantCode {
+				t.Fatalf("status %d code %d, want 401 %d", status, code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestWebhookReplay(t *testing.T) {
+	r := newWebhookRouter(t, true)
+	now := time.Now()
+
+	if status, _, body := serve(r, signedRequest("new-secret", "acme", "n-replay", now, webhookBody)); status != http.StatusOK {
+		t.Fatalf("first request status %d body %s", status, body)
+	}
+	status, code, _ := serve(r, signedRequest("new-secret", "acme", "n-replay", now, webhookBody))
+	if status != http.StatusConflict || code != errcode.RequestReplayed.Code {
+		t.Fatalf("replay status %d code %d, want 409 %d", status, code, errcode.RequestReplayed.Code)
+	}
+
+	// 签名无效的请求不占用随机串，之后携带该随机串的合法请求仍可通过
+	serve(r, signedRequest("wrong-secret", "acme", "n-forged", now, webhookBody))
+	if status, _, body := serve(r, signedRequest("new-secret", "acme", "n-forged", now, webhookBody)); status != http.StatusOK {
+		t.Fatalf("status %d body %s after forged request", status, body)
+	}
+}
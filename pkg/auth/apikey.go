@@ -0,0 +1,370 @@
+package auth
+
+import (
+	"FastGin/pkg/config"
+	"FastGin/pkg/errcode"
+	"FastGin/pkg/logg"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// APIKeyPrefix API密钥前缀，完整密钥格式为 fgk_<id>.<secret>
+const APIKeyPrefix = "fgk_"
+
+// DefaultTouchInterval 最近使用时间的默认写入间隔
+const DefaultTouchInterval = time.Minute
+
+// apiKeyIDPattern 密钥ID只允许字母、数字、下划线和短横线
+var apiKeyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// APIKey API密钥记录，只保存完整密钥的SHA-256摘要
+type APIKey struct {
+	ID         string                 `json:"id"`
+	Name       string                 `json:"name"`
+	Hash       string                 `json:"-"`
+	Scopes     []config.APIKeyScope   `json:"scopes"`
+	AllowIPs   []string               `json:"allow_ips,omitempty"`
+	RateLimit  config.APIKeyRateLimit `json:"rate_limit"`
+	CreatedAt  time.Time              `json:"created_at"`
+	ExpiresAt  *time.Time             `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time             `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time             `json:"last_used_at,omitempty"` // 按touch_interval节流写入，存在一定延迟
+	Seeded     bool                   `json:"seeded,omitempty"`       // 来自配置的预置密钥，每次启动按配置同步
+}
+
+// Allows 判断密钥的访问范围是否包含指定请求，未配置范围时拒绝全部请求
+func (k *APIKey) Allows(method, path string) bool {
+	for _, scope := range k.Scopes {
+		if !matchGroup(scope.Group, path) {
+			continue
+		}
+		if len(scope.Methods) == 0 {
+			return true
+		}
+		for _, m := range scope.Methods {
+			if strings.EqualFold(m, method) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AllowsIP 判断来源IP是否在白名单内，未配置白名单时不限制
+func (k *APIKey) AllowsIP(ip string) bool {
+	if len(k.AllowIPs) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, entry := range k.AllowIPs {
+		if prefix, err := parsePrefix(entry); err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAPIKeyParams 创建API密钥的参数
+type CreateAPIKeyParams struct {
+	Name      string
+	Scopes    []config.APIKeyScope
+	AllowIPs  []string
+	RateLimit config.APIKeyRateLimit
+	ExpiresAt *time.Time
+}
+
+// APIKeys API密钥的创建、吊销和验证
+type APIKeys struct {
+	store         KeyStore
+	touchInterval time.Duration
+}
+
+// NewAPIKeys 根据配置创建API密钥管理器，并将预置密钥同步到存储，store为nil时使用进程内存储
+func NewAPIKeys(cfg config.APIKeysConfig, store KeyStore) (*APIKeys, error) {
+	if cfg.TouchInterval <= 0 {
+		cfg.TouchInterval = DefaultTouchInterval
+	}
+	if store == nil {
+		store = NewMemoryKeyStore()
+	}
+
+	if err := syncSeeded(context.Background(), store, cfg.Keys); err != nil {
+		return nil, err
+	}
+	return &APIKeys{store: store, touchInterval: cfg.TouchInterval}, nil
+}
+
+// syncSeeded 按配置更新存储中的预置密钥，并吊销已从配置中删除的预置密钥
+// 通过管理接口吊销的预置密钥在摘要不变时保持吊销，更换密钥后恢复可用；
+// 同ID的密钥不是预置密钥且摘要不同时拒绝启动，避免覆盖通过管理接口创建的密钥
+func syncSeeded(ctx context.Context, store KeyStore, configs []config.APIKeyConfig) error {
+	existing, err := store.List(ctx)
+	if err != nil {
+		return fmt.Errorf("auth: 读取API密钥失败: %w", err)
+	}
+	stored := make(map[string]*APIKey, len(existing))
+	for _, key := range existing {
+		stored[key.ID] = key
+	}
+
+	seeded := make(map[string]bool, len(configs))
+	for _, kc := range configs {
+		key, err := seedKey(kc)
+		if err != nil {
+			return err
+		}
+		if seeded[key.ID] {
+			return fmt.Errorf("auth: 预置API密钥 %s 重复配置", key.ID)
+		}
+		seeded[key.ID] = true
+
+		if old := stored[key.ID]; old != nil {
+			// 早期版本写入的预置密钥没有seeded标记，摘要相同即视为同一个密钥
+			if !old.Seeded && old.Hash != key.Hash {
+				return fmt.Errorf("auth: 预置API密钥 %s 与存储中同ID的密钥不一致，请更换ID或先删除该密钥", key.ID)
+			}
+			key.CreatedAt = old.CreatedAt
+			key.LastUsedAt = old.LastUsedAt
+			if old.Hash == key.Hash {
+				key.RevokedAt = old.RevokedAt
+			}
+		}
+		if err := store.Put(ctx, key); err != nil {
+			return fmt.Errorf("auth: 写入预置API密钥 %s 失败: %w", key.ID, err)
+		}
+	}
+
+	now := time.Now()
+	for _, old := range existing {
+		if !old.Seeded || seeded[old.ID] || old.RevokedAt != nil {
+			continue
+		}
+		if _, err := store.Revoke(ctx, old.ID, now); err != nil {
+			return fmt.Errorf("auth: 吊销已删除的预置API密钥 %s 失败: %w", old.ID, err)
+		}
+		logg.Info("吊销已从配置中删除的预置API密钥", map[string]interface{}{
+			"key_id": old.ID,
+		})
+	}
+	return nil
+}
+
+// Create 生成新的API密钥，返回的明文密钥只在创建时出现一次
+func (m *APIKeys) Create(ctx context.Context, params CreateAPIKeyParams) (*APIKey, string, error) {
+	if err := validateKey(params.Scopes, params.AllowIPs); err != nil {
+		return nil, "", errcode.InvalidParams.Wrap(err)
+	}
+
+	id, err := randomString(8, hex.EncodeToString)
+	if err != nil {
+		return nil, "", errcode.InternalServerError.Wrap(err)
+	}
+	secret, err := randomString(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return nil, "", errcode.InternalServerError.Wrap(err)
+	}
+	plain := APIKeyPrefix + id + "." + secret
+
+	key := &APIKey{
+		ID:        id,
+		Name:      params.Name,
+		Hash:      HashAPIKey(plain),
+		Scopes:    params.Scopes,
+		AllowIPs:  params.AllowIPs,
+		RateLimit: params.RateLimit,
+		CreatedAt: time.Now(),
+		ExpiresAt: params.ExpiresAt,
+	}
+	created, err := m.store.Create(ctx, key)
+	if err != nil {
+		return nil, "", errcode.InternalServerError.Wrap(err)
+	}
+	if !created {
+		return nil, "", errcode.InternalServerError.Wrap(fmt.Errorf("auth: API密钥ID %s 冲突", id))
+	}
+
+	logg.Info("创建API密钥", map[string]interface{}{
+		"key_id": id,
+		"name":   params.Name,
+	})
+	return key, plain, nil
+}
+
+// List 按创建时间列出全部API密钥，包括已吊销和已过期的密钥
+func (m *APIKeys) List(ctx context.Context) ([]*APIKey, error) {
+	keys, err := m.store.List(ctx)
+	if err != nil {
+		return nil, errcode.InternalServerError.Wrap(err)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+// Revoke 吊销API密钥，密钥不存在时返回errcode.NotFound
+func (m *APIKeys) Revoke(ctx context.Context, id string) error {
+	found, err := m.store.Revoke(ctx, id, time.Now())
+	if err != nil {
+		return errcode.InternalServerError.Wrap(err)
+	}
+	if !found {
+		return errcode.NotFound
+	}
+
+	logg.Info("吊销API密钥", map[string]interface{}{
+		"key_id": id,
+	})
+	return nil
+}
+
+// Authenticate 验证API密钥及其有效期、来源IP和访问范围，并更新最近使用时间
+// 密钥无效返回errcode.APIKeyInvalid，已过期返回errcode.APIKeyExpired，已吊销返回errcode.APIKeyRevoked，
+// 来源IP或访问范围不允许时返回errcode.Forbidden
+func (m *APIKeys) Authenticate(ctx context.Context, plain, method, path, clientIP string) (*APIKey, error) {
+	id, ok := parseAPIKey(plain)
+	if !ok {
+		return nil, errcode.APIKeyInvalid
+	}
+	key, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, errcode.InternalServerError.Wrap(err)
+	}
+	if key == nil || subtle.ConstantTimeCompare([]byte(key.Hash), []byte(HashAPIKey(plain))) != 1 {
+		return nil, errcode.APIKeyInvalid
+	}
+
+	now := time.Now()
+	switch {
+	case key.RevokedAt != nil:
+		return nil, errcode.APIKeyRevoked
+	case key.ExpiresAt != nil && now.After(*key.ExpiresAt):
+		return nil, errcode.APIKeyExpired
+	case !key.AllowsIP(clientIP):
+		return nil, errcode.Forbidden.Wrap(fmt.Errorf("auth: API密钥 %s 不允许来源IP %s", id, clientIP))
+	case !key.Allows(method, path):
+		return nil, errcode.Forbidden.Wrap(fmt.Errorf("auth: API密钥 %s 无权访问 %s %s", id, method, path))
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= m.touchInterval {
+		if err := m.store.Touch(ctx, id, now); err != nil {
+			logg.Warn("更新API密钥最近使用时间失败", map[string]interface{}{
+				"key_id": id,
+				"error":  err.Error(),
+			})
+		}
+	}
+	return key, nil
+}
+
+// HashAPIKey 计算完整密钥的SHA-256十六进制摘要，预置密钥的hash使用相同算法
+func HashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// parseAPIKey 从 fgk_<id>.<secret> 中解析密钥ID
+func parseAPIKey(plain string) (string, bool) {
+	rest, ok := strings.CutPrefix(plain, APIKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	id, secret, ok := strings.Cut(rest, ".")
+	if !ok || secret == "" || !apiKeyIDPattern.MatchString(id) {
+		return "", false
+	}
+	return id, true
+}
+
+// seedKey 将配置中的预置密钥转换为密钥记录
+func seedKey(kc config.APIKeyConfig) (*APIKey, error) {
+	if !apiKeyIDPattern.MatchString(kc.ID) {
+		return nil, fmt.Errorf("auth: API密钥ID %q 无效", kc.ID)
+	}
+	hash := strings.ToLower(kc.Hash)
+	if raw, err := hex.DecodeString(hash); err != nil || len(raw) != sha256.Size {
+		return nil, fmt.Errorf("auth: API密钥 %s 的hash应为SHA-256十六进制摘要", kc.ID)
+	}
+	if err := validateKey(kc.Scopes, kc.AllowIPs); err != nil {
+		return nil, fmt.Errorf("auth: API密钥 %s: %w", kc.ID, err)
+	}
+
+	key := &APIKey{
+		ID:        kc.ID,
+		Name:      kc.Name,
+		Hash:      hash,
+		Scopes:    kc.Scopes,
+		AllowIPs:  kc.AllowIPs,
+		RateLimit: kc.RateLimit,
+		CreatedAt: time.Now(),
+		Seeded:    true,
+	}
+	if !kc.ExpiresAt.IsZero() {
+		expiresAt := kc.ExpiresAt
+		key.ExpiresAt = &expiresAt
+	}
+	return key, nil
+}
+
+// validateKey 校验访问范围和IP白名单
+func validateKey(scopes []config.APIKeyScope, allowIPs []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("至少需要一个访问范围")
+	}
+	for _, scope := range scopes {
+		if !strings.HasPrefix(scope.Group, "/") {
+			return fmt.Errorf("访问范围 %q 应以 / 开头", scope.Group)
+		}
+	}
+	for _, entry := range allowIPs {
+		if _, err := parsePrefix(entry); err != nil {
+			return fmt.Errorf("IP白名单 %q 无效", entry)
+		}
+	}
+	return nil
+}
+
+// parsePrefix 解析CIDR，单个IP视为只包含自身的网段
+func parsePrefix(entry string) (netip.Prefix, error) {
+	if strings.Contains(entry, "/") {
+		return netip.ParsePrefix(entry)
+	}
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// matchGroup 按路径段匹配路由组前缀，/admin 匹配 /admin 和 /admin/...，不匹配 /administrator
+func matchGroup(group, path string) bool {
+	group = strings.TrimSuffix(group, "/")
+	if group == "" {
+		return true
+	}
+	return path == group || strings.HasPrefix(path, group+"/")
+}
+
+// randomString 生成n字节随机数并编码
+func randomString(n int, encode func([]byte) string) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encode(b), nil
+}
@@ -0,0 +1,285 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// KeyStore API密钥存储
+type KeyStore interface {
+	// Create 写入新密钥，同ID的密钥已存在时不覆盖并返回false
+	Create(ctx context.Context, key *APIKey) (bool, error)
+	// Put 写入密钥，同ID的密钥已存在时覆盖，用于同步预置密钥
+	Put(ctx context.Context, key *APIKey) error
+	// Get 读取密钥，不存在时返回nil
+	Get(ctx context.Context, id string) (*APIKey, error)
+	// List 读取全部密钥
+	List(ctx context.Context) ([]*APIKey, error)
+	// Revoke 标记密钥已吊销，密钥不存在时返回false
+	Revoke(ctx context.Context, id string, at time.Time) (bool, error)
+	// Touch 更新密钥的最近使用时间
+	Touch(ctx context.Context, id string, at time.Time) error
+}
+
+// NewKeyStore 根据配置创建API密钥存储，redis存储使用传入的客户端
+func NewKeyStore(store string, client *redis.Client) (KeyStore, error) {
+	switch store {
+	case "", "memory":
+		return NewMemoryKeyStore(), nil
+	case "redis":
+		if client == nil {
+			return nil, fmt.Errorf("auth: API密钥存储需要配置 redis")
+		}
+		return NewRedisKeyStore(client), nil
+	default:
+		return nil, fmt.Errorf("auth: 未知的API密钥存储 %q", store)
+	}
+}
+
+// MemoryKeyStore 进程内API密钥存储，重启后通过管理接口创建的密钥和吊销记录都会丢失
+type MemoryKeyStore struct {
+	mu   sync.RWMutex
+	keys map[string]*APIKey
+}
+
+// NewMemoryKeyStore 创建进程内API密钥存储
+func NewMemoryKeyStore() *MemoryKeyStore {
+	return &MemoryKeyStore{keys: make(map[string]*APIKey)}
+}
+
+// Create 写入新密钥
+func (s *MemoryKeyStore) Create(_ context.Context, key *APIKey) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.keys[key.ID]; ok {
+		return false, nil
+	}
+	cp := *key
+	s.keys[key.ID] = &cp
+	return true, nil
+}
+
+// Put 写入或覆盖密钥
+func (s *MemoryKeyStore) Put(_ context.Context, key *APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cp := *key
+	s.keys[key.ID] = &cp
+	return nil
+}
+
+// Get 读取密钥副本
+func (s *MemoryKeyStore) Get(_ context.Context, id string) (*APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, nil
+	}
+	cp := *key
+	return &cp, nil
+}
+
+// List 读取全部密钥副本
+func (s *MemoryKeyStore) List(_ context.Context) ([]*APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]*APIKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		cp := *key
+		keys = append(keys, &cp)
+	}
+	return keys, nil
+}
+
+// Revoke 标记密钥已吊销，重复吊销保留首次吊销时间
+func (s *MemoryKeyStore) Revoke(_ context.Context, id string, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[id]
+	if !ok {
+		return false, nil
+	}
+	if key.RevokedAt == nil {
+		key.RevokedAt = &at
+	}
+	return true, nil
+}
+
+// Touch 更新最近使用时间
+func (s *MemoryKeyStore) Touch(_ context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.keys[id]; ok {
+		key.LastUsedAt = &at
+	}
+	return nil
+}
+
+// Redis中API密钥的键
+const (
+	redisAPIKeyPrefix   = "auth:apikey:"           // 单个密钥记录
+	redisAPIKeyIndex    = "auth:apikeys"           // 全部密钥ID集合
+	redisAPIKeyLastUsed = "auth:apikeys:last_used" // 密钥ID到最近使用时间（毫秒时间戳）的哈希
+)
+
+// redisKeyRecord Redis中保存的密钥记录，APIKey的Hash字段不参与JSON序列化，需要单独保存
+type redisKeyRecord struct {
+	APIKey
+	Hash string `json:"hash"`
+}
+
+// RedisKeyStore 基于Redis协议的API密钥存储，多实例共享
+type RedisKeyStore struct {
+	client *redis.Client
+}
+
+// NewRedisKeyStore 使用已有的Redis客户端创建API密钥存储
+func NewRedisKeyStore(client *redis.Client) *RedisKeyStore {
+	return &RedisKeyStore{client: client}
+}
+
+// Create 通过SET NX写入新密钥
+func (s *RedisKeyStore) Create(ctx context.Context, key *APIKey) (bool, error) {
+	data, err := json.Marshal(redisKeyRecord{APIKey: *key, Hash: key.Hash})
+	if err != nil {
+		return false, err
+	}
+	created, err := s.client.SetNX(ctx, redisAPIKeyPrefix+key.ID, data, 0).Result()
+	if err != nil || !created {
+		return false, err
+	}
+	return true, s.client.SAdd(ctx, redisAPIKeyIndex, key.ID).Err()
+}
+
+// Put 写入或覆盖密钥，最近使用时间单独保存，不受影响
+func (s *RedisKeyStore) Put(ctx context.Context, key *APIKey) error {
+	data, err := json.Marshal(redisKeyRecord{APIKey: *key, Hash: key.Hash})
+	if err != nil {
+		return err
+	}
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, redisAPIKeyPrefix+key.ID, data, 0)
+		pipe.SAdd(ctx, redisAPIKeyIndex, key.ID)
+		return nil
+	})
+	return err
+}
+
+// Get 读取密钥及其最近使用时间
+func (s *RedisKeyStore) Get(ctx context.Context, id string) (*APIKey, error) {
+	keys, err := s.load(ctx, []string{id})
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+	return keys[0], nil
+}
+
+// List 读取全部密钥
+func (s *RedisKeyStore) List(ctx context.Context) ([]*APIKey, error) {
+	ids, err := s.client.SMembers(ctx, redisAPIKeyIndex).Result()
+	if err != nil {
+		return nil, err
+	}
+	return s.load(ctx, ids)
+}
+
+// Revoke 标记密钥已吊销，重复吊销保留首次吊销时间
+func (s *RedisKeyStore) Revoke(ctx context.Context, id string, at time.Time) (bool, error) {
+	found := false
+	err := s.client.Watch(ctx, func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, redisAPIKeyPrefix+id).Bytes()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+
+		var rec redisKeyRecord
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return err
+		}
+		if rec.RevokedAt != nil {
+			return nil
+		}
+		rec.RevokedAt = &at
+		if data, err = json.Marshal(rec); err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, redisAPIKeyPrefix+id, data, 0)
+			return nil
+		})
+		return err
+	}, redisAPIKeyPrefix+id)
+	return found, err
+}
+
+// Touch 更新最近使用时间
+func (s *RedisKeyStore) Touch(ctx context.Context, id string, at time.Time) error {
+	return s.client.HSet(ctx, redisAPIKeyLastUsed, id, at.UnixMilli()).Err()
+}
+
+// load 批量读取密钥记录和最近使用时间，跳过不存在的密钥
+func (s *RedisKeyStore) load(ctx context.Context, ids []string) ([]*APIKey, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	records := make([]*redis.StringCmd, len(ids))
+	var lastUsed *redis.SliceCmd
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			records[i] = pipe.Get(ctx, redisAPIKeyPrefix+id)
+		}
+		lastUsed = pipe.HMGet(ctx, redisAPIKeyLastUsed, ids...)
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	usedAt := lastUsed.Val()
+	keys := make([]*APIKey, 0, len(ids))
+	for i, cmd := range records {
+		data, err := cmd.Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var rec redisKeyRecord
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return nil, fmt.Errorf("auth: 解析API密钥 %s 失败: %w", ids[i], err)
+		}
+		key := rec.APIKey
+		key.Hash = rec.Hash
+		if i < len(usedAt) {
+			if v, ok := usedAt[i].(string); ok {
+				if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+					t := time.UnixMilli(ms)
+					key.LastUsedAt = &t
+				}
+			}
+		}
+		keys = append(keys, &key)
+	}
+	return keys, nil
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"FastGin/pkg/config"
+	"FastGin/pkg/errcode"
+	"FastGin/pkg/testutil"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	testutil.Main(m)
+}
+
+// seedConfig 预置密钥配置，明文为 fgk_<id>.<secret>
+func seedConfig(id, secret, group string) config.APIKeyConfig {
+	return config.APIKeyConfig{
+		ID:     id,
+		Name:   id,
+		Hash:   HashAPIKey(APIKeyPrefix + id + "." + secret),
+		Scopes: []config.APIKeyScope{{Group: group}},
+	}
+}
+
+func authenticate(m *APIKeys, id, secret, path string) error {
+	_, err := m.Authenticate(context.Background(), APIKeyPrefix+id+"."+secret, "GET", path, "10.0.0.1")
+	return err
+}
+
+func TestSeededKeysFollowConfig(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryKeyStore()
+	if _, err := NewAPIKeys(config.APIKeysConfig{Keys: []config.APIKeyConfig{
+		seedConfig("ops", "s1", "/admin"),
+		seedConfig("report", "r1", "/api"),
+	}}, store); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := store.Get(ctx, "ops")
+
+	// 重启后：ops更换密钥和访问范围，report从配置中删除
+	m, err := NewAPIKeys(config.APIKeysConfig{Keys: []config.APIKeyConfig{
+		seedConfig("ops", "s2", "/internal"),
+	}}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := authenticate(m, "ops", "s1", "/internal/jobs"); !errors.Is(err, errcode.APIKeyInvalid) {
+		t.Fatalf("old secret err %v, want APIKeyInvalid", err)
+	}
+	if err := authenticate(m, "ops", "s2", "/internal/jobs"); err != nil {
+		t.Fatalf("new secret: %v", err)
+	}
+	if err := authenticate(m, "ops", "s2", "/admin/proxies"); !errors.Is(err, errcode.Forbidden) {
+		t.Fatalf("old scope err %v, want Forbidden", err)
+	}
+	if after, _ := store.Get(ctx, "ops"); !after.CreatedAt.Equal(before.CreatedAt) {
+		t.Errorf("created_at changed from %s to %s", before.CreatedAt, after.CreatedAt)
+	}
+	if err := authenticate(m, "report", "r1", "/api/orders"); !errors.Is(err, errcode.APIKeyRevoked) {
+		t.Fatalf("removed key err %v, want APIKeyRevoked", err)
+	}
+}
+
+func TestSeededKeyStaysRevoked(t *testing.T) {
+	store := NewMemoryKeyStore()
+	cfg := config.APIKeysConfig{Keys: []config.APIKeyConfig{seedConfig("ops", "s1", "/admin")}}
+	m, err := NewAPIKeys(cfg, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Revoke(context.Background(), "ops"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 摘要不变时重启不会恢复已吊销的密钥
+	if m, err = NewAPIKeys(cfg, store); err != nil {
+		t.Fatal(err)
+	}
+	if err := authenticate(m, "ops", "s1", "/admin"); !errors.Is(err, errcode.APIKeyRevoked) {
+		t.Fatalf("err %v, want APIKeyRevoked", err)
+	}
+
+	// 更换密钥后恢复可用
+	cfg.Keys[0] = seedConfig("ops", "s2", "/admin")
+	if m, err = NewAPIKeys(cfg, store); err != nil {
+		t.Fatal(err)
+	}
+	if err := authenticate(m, "ops", "s2", "/admin"); err != nil {
+		t.Fatalf("rotated key: %v", err)
+	}
+}
+
+func TestSeededKeyConflict(t *testing.T) {
+	store := NewMemoryKeyStore()
+	store.Create(context.Background(), &APIKey{
+		ID:        "ops",
+		Hash:      HashAPIKey(APIKeyPrefix + "ops.created"),
+		Scopes:    []config.APIKeyScope{{Group: "/admin"}},
+		CreatedAt: time.Now(),
+	})
+
+	// 同ID的非预置密钥摘要不同，拒绝启动而不是覆盖或静默跳过
+	_, err := NewAPIKeys(config.APIKeysConfig{Keys: []config.APIKeyConfig{seedConfig("ops", "s1", "/admin")}}, store)
+	if err == nil {
+		t.Fatal("conflicting seeded key accepted")
+	}
+}
@@ -0,0 +1,311 @@
+package auth
+
+import (
+	"FastGin/pkg/cache"
+	"FastGin/pkg/config"
+	"FastGin/pkg/errcode"
+	"FastGin/pkg/logg"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// 令牌类型
+const (
+	TokenAccess  = "access"
+	TokenRefresh = "refresh"
+)
+
+// 默认令牌有效期
+const (
+	DefaultAccessTTL  = 15 * time.Minute
+	DefaultRefreshTTL = 7 * 24 * time.Hour
+)
+
+// DefaultRevocationCacheTTL 本地缓存吊销检查结果的默认时长
+const DefaultRevocationCacheTTL = 5 * time.Second
+
+// Claims JWT声明
+// Family 为令牌族ID，同一次登录及其后续刷新签发的令牌属于同一族，吊销族即吊销其全部令牌
+type Claims struct {
+	Type   string `json:"typ"`
+	Family string `json:"fam,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// TokenPair 访问令牌与刷新令牌
+type TokenPair struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`         // 访问令牌有效期，单位秒
+	RefreshExpiresIn int64  `json:"refresh_expires_in"` // 刷新令牌有效期，单位秒
+}
+
+// Authenticator JWT签发与验证
+// 按令牌头部的kid选择验证密钥，轮换密钥时新令牌使用active_key签发，旧密钥签发的令牌在过期前仍可验证
+type Authenticator struct {
+	cfg    config.AuthConfig
+	keys   map[string]*key
+	active *key
+	parser *jwt.Parser
+
+	store RevocationStore
+	local *cache.LRU
+}
+
+// New 根据配置创建认证器，store为nil时使用进程内吊销存储，未配置可签名的密钥时返回错误
+func New(cfg config.AuthConfig, store RevocationStore) (*Authenticator, error) {
+	if cfg.AccessTTL <= 0 {
+		cfg.AccessTTL = DefaultAccessTTL
+	}
+	if cfg.RefreshTTL <= 0 {
+		cfg.RefreshTTL = DefaultRefreshTTL
+	}
+	if cfg.Revocation.CacheTTL <= 0 {
+		cfg.Revocation.CacheTTL = DefaultRevocationCacheTTL
+	}
+	if store == nil {
+		store = NewMemoryStore()
+	}
+
+	a := &Authenticator{
+		cfg:   cfg,
+		keys:  make(map[string]*key, len(cfg.Keys)),
+		store: store,
+		local: cache.NewLRU(cfg.Revocation.CacheSize),
+	}
+	for _, kc := range cfg.Keys {
+		k, err := loadKey(kc)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := a.keys[k.kid]; ok {
+			return nil, fmt.Errorf("auth: kid %s 重复", k.kid)
+		}
+		a.keys[k.kid] = k
+		if a.active == nil && k.signKey != nil && (cfg.ActiveKey == "" || cfg.ActiveKey == k.kid) {
+			a.active = k
+		}
+	}
+	if a.active == nil {
+		return nil, fmt.Errorf("auth: 未找到可用于签发的密钥 %q", cfg.ActiveKey)
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"HS256", "RS256", "EdDSA"}),
+		jwt.WithLeeway(cfg.Leeway),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	a.parser = jwt.NewParser(opts...)
+	return a, nil
+}
+
+// Issue 为指定主体签发访问令牌和刷新令牌，开启新的令牌族
+func (a *Authenticator) Issue(subject string) (*TokenPair, error) {
+	return a.issue(subject, uuid.NewString())
+}
+
+// Refresh 使用刷新令牌换取同一族的新令牌对，旧刷新令牌随即失效
+// 已使用过的刷新令牌再次出现时视为被盗用，吊销整个令牌族
+func (a *Authenticator) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	claims, err := a.parse(refreshToken, TokenRefresh)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.checkRevoked(ctx, familyID(claims.Family)); err != nil {
+		return nil, err
+	}
+
+	first, err := a.store.Consume(ctx, tokenID(claims.ID), a.tokenExpiry(claims))
+	if err != nil {
+		return nil, errcode.InternalServerError.Wrap(err)
+	}
+	if !first {
+		logg.Warn("检测到刷新令牌重复使用，吊销令牌族", map[string]interface{}{
+			"subject": claims.Subject,
+			"family":  claims.Family,
+			"jti":     claims.ID,
+		})
+		if err := a.revoke(ctx, familyID(claims.Family), a.familyExpiry()); err != nil {
+			return nil, errcode.InternalServerError.Wrap(err)
+		}
+		return nil, errcode.TokenRevoked
+	}
+	a.local.Set(ctx, tokenID(claims.ID), revokedValue, a.cfg.Revocation.CacheTTL)
+
+	return a.issue(claims.Subject, claims.Family)
+}
+
+// Revoke 吊销令牌及其所在的令牌族，用于退出登录
+func (a *Authenticator) Revoke(ctx context.Context, claims *Claims) error {
+	if err := a.revoke(ctx, tokenID(claims.ID), a.tokenExpiry(claims)); err != nil {
+		return errcode.InternalServerError.Wrap(err)
+	}
+	if claims.Family != "" {
+		if err := a.revoke(ctx, familyID(claims.Family), a.familyExpiry()); err != nil {
+			return errcode.InternalServerError.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// Verify 验证令牌签名、有效期、签发者、受众、类型和吊销状态
+// 过期返回errcode.TokenExpired，已吊销返回errcode.TokenRevoked，其他验证失败返回errcode.TokenInvalid
+func (a *Authenticator) Verify(ctx context.Context, tokenString, typ string) (*Claims, error) {
+	claims, err := a.parse(tokenString, typ)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.checkRevoked(ctx, tokenID(claims.ID)); err != nil {
+		return nil, err
+	}
+	if claims.Family != "" {
+		if err := a.checkRevoked(ctx, familyID(claims.Family)); err != nil {
+			return nil, err
+		}
+	}
+	return claims, nil
+}
+
+// parse 验证令牌签名、有效期、签发者、受众和类型，不检查吊销状态
+func (a *Authenticator) parse(tokenString, typ string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := a.parser.ParseWithClaims(tokenString, claims, a.keyFunc)
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return nil, errcode.TokenExpired.Wrap(err)
+	case err != nil:
+		return nil, errcode.TokenInvalid.Wrap(err)
+	case claims.Type != typ:
+		return nil, errcode.TokenInvalid.Wrap(fmt.Errorf("auth: 令牌类型为 %q，需要 %q", claims.Type, typ))
+	}
+	return claims, nil
+}
+
+// 本地缓存中的吊销检查结果
+var (
+	revokedValue    = []byte{1}
+	notRevokedValue = []byte{0}
+)
+
+// checkRevoked 查询吊销状态，先查本地缓存，未命中时查询吊销存储
+// 存储不可用时拒绝请求，避免已吊销的令牌在故障期间重新生效
+func (a *Authenticator) checkRevoked(ctx context.Context, id string) error {
+	if v, ok, _ := a.local.Get(ctx, id); ok {
+		if v[0] == revokedValue[0] {
+			return errcode.TokenRevoked
+		}
+		return nil
+	}
+
+	revoked, err := a.store.Revoked(ctx, id)
+	if err != nil {
+		return errcode.InternalServerError.Wrap(err)
+	}
+	if revoked {
+		a.local.Set(ctx, id, revokedValue, a.cfg.Revocation.CacheTTL)
+		return errcode.TokenRevoked
+	}
+	a.local.Set(ctx, id, notRevokedValue, a.cfg.Revocation.CacheTTL)
+	return nil
+}
+
+// revoke 写入吊销存储并立即更新本地缓存
+func (a *Authenticator) revoke(ctx context.Context, id string, until time.Time) error {
+	if err := a.store.Revoke(ctx, id, until); err != nil {
+		return err
+	}
+	a.local.Set(ctx, id, revokedValue, a.cfg.Revocation.CacheTTL)
+	return nil
+}
+
+// tokenExpiry 单个令牌吊销记录的保留期限，解析时允许leeway的时钟偏差，记录需保留到令牌无法再通过验证为止
+func (a *Authenticator) tokenExpiry(claims *Claims) time.Time {
+	return claims.ExpiresAt.Add(a.cfg.Leeway)
+}
+
+// familyExpiry 令牌族吊销记录的保留期限，覆盖族内最晚签发的刷新令牌
+func (a *Authenticator) familyExpiry() time.Time {
+	return time.Now().Add(a.cfg.RefreshTTL + a.cfg.Leeway)
+}
+
+// tokenID 单个令牌在吊销存储中的ID
+func tokenID(jti string) string {
+	return "jti:" + jti
+}
+
+// familyID 令牌族在吊销存储中的ID
+func familyID(family string) string {
+	return "fam:" + family
+}
+
+// keyFunc 按kid选择验证密钥，并要求令牌算法与密钥算法一致，防止算法混淆
+func (a *Authenticator) keyFunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	k, ok := a.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("auth: 未知的kid %q", kid)
+	}
+	if t.Method.Alg() != k.method.Alg() {
+		return nil, fmt.Errorf("auth: kid %s 的算法为 %s，令牌使用 %s", kid, k.method.Alg(), t.Method.Alg())
+	}
+	return k.verifyKey, nil
+}
+
+// issue 在指定令牌族中签发令牌对
+func (a *Authenticator) issue(subject, family string) (*TokenPair, error) {
+	access, err := a.sign(subject, family, TokenAccess, a.cfg.AccessTTL)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := a.sign(subject, family, TokenRefresh, a.cfg.RefreshTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:      access,
+		RefreshToken:     refresh,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(a.cfg.AccessTTL / time.Second),
+		RefreshExpiresIn: int64(a.cfg.RefreshTTL / time.Second),
+	}, nil
+}
+
+// sign 使用当前签发密钥签名
+func (a *Authenticator) sign(subject, family, typ string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := &Claims{
+		Type:   typ,
+		Family: family,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   subject,
+			Issuer:    a.cfg.Issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	if a.cfg.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{a.cfg.Audience}
+	}
+
+	token := jwt.NewWithClaims(a.active.method, claims)
+	token.Header["kid"] = a.active.kid
+	signed, err := token.SignedString(a.active.signKey)
+	if err != nil {
+		return "", fmt.Errorf("auth: 签发令牌失败: %w", err)
+	}
+	return signed, nil
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"FastGin/pkg/config"
+	"FastGin/pkg/errcode"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingStore 记录Consume收到的保留期限
+type recordingStore struct {
+	*MemoryStore
+	until time.Time
+}
+
+func (s *recordingStore) Consume(ctx context.Context, id string, until time.Time) (bool, error) {
+	s.until = until
+	return s.MemoryStore.Consume(ctx, id, until)
+}
+
+func newTestAuthenticator(t *testing.T, store RevocationStore) *Authenticator {
+	t.Helper()
+	a, err := New(config.AuthConfig{
+		Issuer:    "fastgin-test",
+		Leeway:    30 * time.Second,
+		ActiveKey: "test",
+		Keys:      []config.JWTKeyConfig{{KID: "test", Alg: "HS256", Secret: strings.Repeat("k", 32)}},
+	}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestRefreshKeepsRecordThroughLeeway(t *testing.T) {
+	store := &recordingStore{MemoryStore: NewMemoryStore()}
+	a := newTestAuthenticator(t, store)
+	ctx := context.Background()
+
+	pair, err := a.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := a.parse(pair.RefreshToken, TokenRefresh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Refresh(ctx, pair.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+
+	// 过期后leeway内令牌仍能通过解析，一次性使用记录需保留到那时
+	if want := claims.ExpiresAt.Add(30 * time.Second); !store.until.Equal(want) {
+		t.Fatalf("consume until %s, want exp+leeway %s", store.until, want)
+	}
+	if _, err := a.Refresh(ctx, pair.RefreshToken); !errors.Is(err, errcode.TokenRevoked) {
+		t.Fatalf("reuse err %v, want TokenRevoked", err)
+	}
+}
+
+func TestConsumePastUntil(t *testing.T) {
+	// until已过时不视为重复使用，与RedisStore的行为一致
+	store := NewMemoryStore()
+	past := time.Now().Add(-time.Second)
+	for i := 0; i < 2; i++ {
+		if first, err := store.Consume(context.Background(), "jti:x", past); err != nil || !first {
+			t.Fatalf("consume %d: first %v err %v", i, first, err)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// 认证中间件写入gin.Context的键
+const (
+	ContextKey       = "Claims"    // JWT声明
+	APIKeyContextKey = "APIKey"    // API密钥记录
+	PrincipalKey     = "Principal" // 认证主体，用户为 user:<subject>，API密钥为 key:<id>，合作方为 partner:<id>
+)
+
+// ClaimsFrom 读取认证中间件写入的声明，未认证时ok为false
+func ClaimsFrom(c *gin.Context) (*Claims, bool) {
+	v, ok := c.Get(ContextKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := v.(*Claims)
+	return claims, ok
+}
+
+// Subject 返回当前认证主体，未认证时返回空串
+func Subject(c *gin.Context) string {
+	if claims, ok := ClaimsFrom(c); ok {
+		return claims.Subject
+	}
+	return ""
+}
+
+// APIKeyFrom 读取API密钥认证中间件写入的密钥记录，未使用API密钥认证时ok为false
+func APIKeyFrom(c *gin.Context) (*APIKey, bool) {
+	v, ok := c.Get(APIKeyContextKey)
+	if !ok {
+		return nil, false
+	}
+	key, ok := v.(*APIKey)
+	return key, ok
+}
+
+// Principal 返回当前认证主体，用户为 user:<subject>，API密钥为 key:<id>，合作方为 partner:<id>，未认证时返回空串
+// 各类主体使用不同前缀，避免用户名与API密钥等主体相同时获得对方的角色
+func Principal(c *gin.Context) string {
+	return c.GetString(PrincipalKey)
+}
+
+// UserPrincipal JWT用户对应的认证主体
+func UserPrincipal(subject string) string {
+	return "user:" + subject
+}
+
+// PartnerPrincipal 通过回调签名验证的合作方对应的认证主体
+func PartnerPrincipal(id string) string {
+	return "partner:" + id
+}
+
+// APIKeyPrincipal API密钥对应的认证主体
+func APIKeyPrincipal(id string) string {
+	return "key:" + id
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"FastGin/pkg/config"
+	"context"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrBadCredentials 用户名不存在或密码错误
+var ErrBadCredentials = errors.New("auth: 用户名或密码错误")
+
+// dummyHash 用户名不存在时用于比较的bcrypt摘要，使响应耗时与密码错误一致，避免枚举用户名
+var dummyHash = []byte("$2a$10$dbdNe0CeBMGBhk9FmELoKul1Co1PQMjQppkzPvr1kc5p2567id7Le")
+
+// CredentialVerifier 校验登录凭据，接入数据库等账号系统时实现该接口
+type CredentialVerifier interface {
+	// Verify 校验用户名和密码，凭据错误时返回ErrBadCredentials
+	Verify(ctx context.Context, username, password string) error
+}
+
+// Users 配置中的本地账号，密码以bcrypt摘要保存，未配置账号时拒绝全部登录
+type Users struct {
+	hashes map[string][]byte
+}
+
+// NewUsers 根据配置创建本地账号，用户名重复或摘要无效时返回错误
+func NewUsers(cfg []config.UserConfig) (*Users, error) {
+	u := &Users{hashes: make(map[string][]byte, len(cfg))}
+	for _, uc := range cfg {
+		if uc.Username == "" {
+			return nil, fmt.Errorf("auth: 账号缺少username")
+		}
+		if _, ok := u.hashes[uc.Username]; ok {
+			return nil, fmt.Errorf("auth: 账号 %s 重复", uc.Username)
+		}
+		hash := []byte(uc.PasswordHash)
+		if _, err := bcrypt.Cost(hash); err != nil {
+			return nil, fmt.Errorf("auth: 账号 %s 的password_hash不是有效的bcrypt摘要: %w", uc.Username, err)
+		}
+		u.hashes[uc.Username] = hash
+	}
+	return u, nil
+}
+
+// Len 返回账号数
+func (u *Users) Len() int {
+	return len(u.hashes)
+}
+
+// Verify 校验用户名和密码
+func (u *Users) Verify(_ context.Context, username, password string) error {
+	hash, ok := u.hashes[username]
+	if !ok {
+		hash = dummyHash
+	}
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil || !ok {
+		return ErrBadCredentials
+	}
+	return nil
+}
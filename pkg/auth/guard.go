@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"FastGin/pkg/config"
+	"FastGin/pkg/errcode"
+	"FastGin/pkg/logg"
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 登录失败保护默认值
+const (
+	DefaultLoginWindow       = 15 * time.Minute
+	DefaultLoginDelayAfter   = 3
+	DefaultLoginBaseDelay    = time.Second
+	DefaultLoginMaxDelay     = 30 * time.Second
+	DefaultUserLockAfter     = 5
+	DefaultIPLockAfter       = 20
+	DefaultLoginLockDuration = 15 * time.Minute
+)
+
+// loginInflightTTL 同一用户名进行中登录尝试的最长占用时间，请求异常中断未释放时到期自动释放
+const loginInflightTTL = 30 * time.Second
+
+// LoginStatus 登录失败保护的检查结果
+type LoginStatus struct {
+	Locked          bool `json:"locked,omitempty"`
+	RetryAfter      int  `json:"retry_after,omitempty"` // 下次尝试前需要等待的秒数
+	CaptchaRequired bool `json:"captcha_required,omitempty"`
+}
+
+// Blocked 当前是否拒绝登录尝试
+func (s *LoginStatus) Blocked() bool {
+	return s.Locked || s.RetryAfter > 0
+}
+
+// Err 拒绝登录时返回的错误，锁定返回errcode.LoginLocked，需要等待返回errcode.LoginThrottled
+func (s *LoginStatus) Err() error {
+	if s.Locked {
+		return errcode.LoginLocked.WithDetails(s)
+	}
+	return errcode.LoginThrottled.WithDetails(s)
+}
+
+// LoginGuard 登录失败保护：按用户名和来源IP统计失败次数，连续失败后要求逐次加倍等待，超过阈值后临时锁定
+type LoginGuard struct {
+	cfg   config.LoginGuardConfig
+	store AttemptStore
+}
+
+// NewLoginGuard 根据配置创建登录失败保护，store为nil时使用进程内存储
+func NewLoginGuard(cfg config.LoginGuardConfig, store AttemptStore) *LoginGuard {
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultLoginWindow
+	}
+	if cfg.DelayAfter <= 0 {
+		cfg.DelayAfter = DefaultLoginDelayAfter
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = DefaultLoginBaseDelay
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = DefaultLoginMaxDelay
+	}
+	if cfg.UserLockAfter <= 0 {
+		cfg.UserLockAfter = DefaultUserLockAfter
+	}
+	if cfg.IPLockAfter <= 0 {
+		cfg.IPLockAfter = DefaultIPLockAfter
+	}
+	if cfg.LockDuration <= 0 {
+		cfg.LockDuration = DefaultLoginLockDuration
+	}
+	if store == nil {
+		store = NewMemoryAttemptStore()
+	}
+	return &LoginGuard{cfg: cfg, store: store}
+}
+
+// Begin 开始一次登录尝试：占用该用户名唯一的进行中名额后检查是否被锁定或需要等待。
+// 前一次尝试记录结果前，同一用户名的并发尝试直接被拒绝，避免并发请求在失败计数生效前绕过等待和锁定。
+// err为nil时调用方必须在Fail或Succeed之后调用done释放名额
+func (g *LoginGuard) Begin(ctx context.Context, username, ip string) (status *LoginStatus, done func(), err error) {
+	key := userAttemptKey(username)
+	token := uuid.NewString()
+	acquired, err := g.store.Acquire(ctx, key, token, loginInflightTTL)
+	if err != nil {
+		return nil, nil, errcode.InternalServerError.Wrap(err)
+	}
+	if !acquired {
+		return &LoginStatus{RetryAfter: 1}, func() {}, nil
+	}
+	done = func() {
+		// 入站请求取消后仍需释放名额
+		if err := g.store.Release(context.WithoutCancel(ctx), key, token); err != nil {
+			logg.Error("释放登录名额失败:", err)
+		}
+	}
+
+	status, err = g.Check(ctx, username, ip)
+	if err != nil {
+		done()
+		return nil, nil, err
+	}
+	return status, done, nil
+}
+
+// Check 在调用上游登录前检查用户名和来源IP是否被锁定或需要等待
+func (g *LoginGuard) Check(ctx context.Context, username, ip string) (*LoginStatus, error) {
+	now := time.Now()
+	user, err := g.store.Get(ctx, userAttemptKey(username))
+	if err != nil {
+		return nil, errcode.InternalServerError.Wrap(err)
+	}
+	addr, err := g.store.Get(ctx, ipAttemptKey(ip))
+	if err != nil {
+		return nil, errcode.InternalServerError.Wrap(err)
+	}
+
+	status := &LoginStatus{CaptchaRequired: g.captchaRequired(user.Failures)}
+	wait := user.Last.Add(g.delay(user.Failures)).Sub(now)
+	for _, a := range []Attempts{user, addr} {
+		if locked := a.LockedUntil.Sub(now); locked > 0 {
+			status.Locked = true
+			wait = max(wait, locked)
+		}
+	}
+	status.RetryAfter = seconds(wait)
+	return status, nil
+}
+
+// Fail 记录一次密码错误，达到阈值时锁定并写入审计日志，返回下次尝试前需要等待的时长和是否需要验证码
+func (g *LoginGuard) Fail(ctx context.Context, username, ip string) (*LoginStatus, error) {
+	now := time.Now()
+
+	user, err := g.store.Fail(ctx, userAttemptKey(username), now, g.cfg.Window)
+	if err != nil {
+		return nil, errcode.InternalServerError.Wrap(err)
+	}
+	addr, err := g.store.Fail(ctx, ipAttemptKey(ip), now, g.cfg.Window)
+	if err != nil {
+		return nil, errcode.InternalServerError.Wrap(err)
+	}
+
+	status := &LoginStatus{CaptchaRequired: g.captchaRequired(user.Failures)}
+	wait := g.delay(user.Failures)
+	for _, t := range []struct {
+		key       string
+		failures  int
+		threshold int
+	}{
+		{userAttemptKey(username), user.Failures, g.cfg.UserLockAfter},
+		{ipAttemptKey(ip), addr.Failures, g.cfg.IPLockAfter},
+	} {
+		if t.failures < t.threshold {
+			continue
+		}
+		until := now.Add(g.cfg.LockDuration)
+		if err := g.store.Lock(ctx, t.key, until, max(g.cfg.Window, g.cfg.LockDuration)); err != nil {
+			return nil, errcode.InternalServerError.Wrap(err)
+		}
+		logg.Warn("登录失败次数过多，已临时锁定", map[string]interface{}{
+			"audit":        "login_locked",
+			"target":       t.key,
+			"failures":     t.failures,
+			"locked_until": until.Format(time.RFC3339),
+		})
+		status.Locked = true
+		wait = g.cfg.LockDuration
+	}
+	status.RetryAfter = seconds(wait)
+	return status, nil
+}
+
+// Succeed 登录成功后清除该用户名的失败记录，来源IP的记录保留，避免攻击者用自己的账号重置IP计数
+func (g *LoginGuard) Succeed(ctx context.Context, username string) error {
+	if err := g.store.Reset(ctx, userAttemptKey(username)); err != nil {
+		return errcode.InternalServerError.Wrap(err)
+	}
+	return nil
+}
+
+// Unlock 解除用户名或来源IP的锁定并清除失败记录，operator为执行解锁的认证主体，写入审计日志
+func (g *LoginGuard) Unlock(ctx context.Context, username, ip, operator string) error {
+	var keys []string
+	if username != "" {
+		keys = append(keys, userAttemptKey(username))
+	}
+	if ip != "" {
+		keys = append(keys, ipAttemptKey(ip))
+	}
+
+	for _, key := range keys {
+		if err := g.store.Reset(ctx, key); err != nil {
+			return errcode.InternalServerError.Wrap(err)
+		}
+		logg.Info("登录锁定已解除", map[string]interface{}{
+			"audit":    "login_unlocked",
+			"target":   key,
+			"operator": operator,
+		})
+	}
+	return nil
+}
+
+// delay 失败failures次后下次尝试前需要等待的时长，未达到delay_after时为0
+func (g *LoginGuard) delay(failures int) time.Duration {
+	if failures < g.cfg.DelayAfter {
+		return 0
+	}
+	d := g.cfg.BaseDelay
+	for i := g.cfg.DelayAfter; i < failures && d < g.cfg.MaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, g.cfg.MaxDelay)
+}
+
+// captchaRequired 失败次数是否达到要求验证码的阈值
+func (g *LoginGuard) captchaRequired(failures int) bool {
+	return g.cfg.CaptchaAfter > 0 && failures >= g.cfg.CaptchaAfter
+}
+
+// userAttemptKey 用户名的失败记录键，用户名不区分大小写
+func userAttemptKey(username string) string {
+	return "user:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+// ipAttemptKey 来源IP的失败记录键
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+// seconds 向上取整为秒，负数视为0
+func seconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
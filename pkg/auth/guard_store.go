@@ -0,0 +1,266 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Attempts 某个用户名或IP的登录失败记录
+type Attempts struct {
+	Failures    int
+	Last        time.Time // 最后一次失败的时间
+	LockedUntil time.Time
+}
+
+// AttemptStore 登录失败记录存储，记录在最后一次写入ttl之后删除
+type AttemptStore interface {
+	// Get 读取失败记录，不存在时返回零值
+	Get(ctx context.Context, key string) (Attempts, error)
+	// Fail 原子地增加失败次数，返回增加后的记录
+	Fail(ctx context.Context, key string, at time.Time, ttl time.Duration) (Attempts, error)
+	// Lock 锁定到until
+	Lock(ctx context.Context, key string, until time.Time, ttl time.Duration) error
+	// Reset 清除失败记录和锁定
+	Reset(ctx context.Context, key string) error
+	// Acquire 以token占用key的进行中名额，已被占用时返回false，名额在ttl后自动释放
+	Acquire(ctx context.Context, key, token string, ttl time.Duration) (bool, error)
+	// Release 释放token占用的名额，名额已过期或被其他token占用时不做处理
+	Release(ctx context.Context, key, token string) error
+}
+
+// NewAttemptStore 根据配置创建登录失败记录存储，redis存储使用传入的客户端
+func NewAttemptStore(store string, client *redis.Client) (AttemptStore, error) {
+	switch store {
+	case "", "memory":
+		return NewMemoryAttemptStore(), nil
+	case "redis":
+		if client == nil {
+			return nil, fmt.Errorf("auth: 登录失败记录存储需要配置 redis")
+		}
+		return NewRedisAttemptStore(client), nil
+	default:
+		return nil, fmt.Errorf("auth: 未知的登录失败记录存储 %q", store)
+	}
+}
+
+// MemoryAttemptStore 进程内登录失败记录存储，仅适用于单实例部署
+type MemoryAttemptStore struct {
+	mu        sync.Mutex
+	attempts  map[string]*memoryAttempts
+	inflight  map[string]memoryInflight
+	lastSweep time.Time
+}
+
+type memoryInflight struct {
+	token   string
+	expires time.Time
+}
+
+type memoryAttempts struct {
+	Attempts
+	expires time.Time
+}
+
+// NewMemoryAttemptStore 创建进程内登录失败记录存储
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{
+		attempts: make(map[string]*memoryAttempts),
+		inflight: make(map[string]memoryInflight),
+	}
+}
+
+// Get 读取失败记录
+func (s *MemoryAttemptStore) Get(_ context.Context, key string) (Attempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if a := s.load(key); a != nil {
+		return a.Attempts, nil
+	}
+	return Attempts{}, nil
+}
+
+// Fail 增加失败次数
+func (s *MemoryAttemptStore) Fail(_ context.Context, key string, at time.Time, ttl time.Duration) (Attempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep()
+	a := s.load(key)
+	if a == nil {
+		a = &memoryAttempts{}
+		s.attempts[key] = a
+	}
+	a.Failures++
+	a.Last = at
+	a.expires = time.Now().Add(ttl)
+	return a.Attempts, nil
+}
+
+// Lock 锁定到until
+func (s *MemoryAttemptStore) Lock(_ context.Context, key string, until time.Time, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a := s.load(key)
+	if a == nil {
+		a = &memoryAttempts{}
+		s.attempts[key] = a
+	}
+	a.LockedUntil = until
+	a.expires = time.Now().Add(ttl)
+	return nil
+}
+
+// Reset 清除失败记录和锁定
+func (s *MemoryAttemptStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
+
+// Acquire 占用进行中名额
+func (s *MemoryAttemptStore) Acquire(_ context.Context, key, token string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if held, ok := s.inflight[key]; ok && now.Before(held.expires) {
+		return false, nil
+	}
+	s.inflight[key] = memoryInflight{token: token, expires: now.Add(ttl)}
+	return true, nil
+}
+
+// Release 释放进行中名额
+func (s *MemoryAttemptStore) Release(_ context.Context, key, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if held, ok := s.inflight[key]; ok && held.token == token {
+		delete(s.inflight, key)
+	}
+	return nil
+}
+
+// load 读取未过期的记录，调用方需持有锁
+func (s *MemoryAttemptStore) load(key string) *memoryAttempts {
+	a, ok := s.attempts[key]
+	if !ok || time.Now().After(a.expires) {
+		return nil
+	}
+	return a
+}
+
+// sweep 每分钟最多清理一次过期记录，调用方需持有锁
+func (s *MemoryAttemptStore) sweep() {
+	now := time.Now()
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, a := range s.attempts {
+		if now.After(a.expires) {
+			delete(s.attempts, key)
+		}
+	}
+	for key, held := range s.inflight {
+		if now.After(held.expires) {
+			delete(s.inflight, key)
+		}
+	}
+}
+
+// redisAttemptPrefix Redis中登录失败记录的键前缀，记录为哈希：f 失败次数，l 最后失败时间，u 锁定截止时间（毫秒时间戳）
+const redisAttemptPrefix = "auth:login:"
+
+// redisInflightPrefix Redis中进行中名额的键前缀，值为占用者的token
+const redisInflightPrefix = "auth:login-inflight:"
+
+// releaseScript 仅在名额仍由token占用时删除，避免超时后释放其他请求的名额
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisAttemptStore 基于Redis协议的登录失败记录存储，多实例共享
+type RedisAttemptStore struct {
+	client *redis.Client
+}
+
+// NewRedisAttemptStore 使用已有的Redis客户端创建登录失败记录存储
+func NewRedisAttemptStore(client *redis.Client) *RedisAttemptStore {
+	return &RedisAttemptStore{client: client}
+}
+
+// Get 读取失败记录
+func (s *RedisAttemptStore) Get(ctx context.Context, key string) (Attempts, error) {
+	fields, err := s.client.HGetAll(ctx, redisAttemptPrefix+key).Result()
+	if err != nil {
+		return Attempts{}, err
+	}
+	return parseAttempts(fields), nil
+}
+
+// Fail 通过MULTI原子地增加失败次数并刷新过期时间
+func (s *RedisAttemptStore) Fail(ctx context.Context, key string, at time.Time, ttl time.Duration) (Attempts, error) {
+	var all *redis.MapStringStringCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HIncrBy(ctx, redisAttemptPrefix+key, "f", 1)
+		pipe.HSet(ctx, redisAttemptPrefix+key, "l", at.UnixMilli())
+		pipe.PExpire(ctx, redisAttemptPrefix+key, ttl)
+		all = pipe.HGetAll(ctx, redisAttemptPrefix+key)
+		return nil
+	})
+	if err != nil {
+		return Attempts{}, err
+	}
+	return parseAttempts(all.Val()), nil
+}
+
+// Lock 锁定到until
+func (s *RedisAttemptStore) Lock(ctx context.Context, key string, until time.Time, ttl time.Duration) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, redisAttemptPrefix+key, "u", until.UnixMilli())
+		pipe.PExpire(ctx, redisAttemptPrefix+key, ttl)
+		return nil
+	})
+	return err
+}
+
+// Reset 清除失败记录和锁定
+func (s *RedisAttemptStore) Reset(ctx context.Context, key string) error {
+	return s.client.Del(ctx, redisAttemptPrefix+key).Err()
+}
+
+// Acquire 通过SET NX原子地占用进行中名额
+func (s *RedisAttemptStore) Acquire(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, redisInflightPrefix+key, token, ttl).Result()
+}
+
+// Release 释放token占用的名额
+func (s *RedisAttemptStore) Release(ctx context.Context, key, token string) error {
+	return releaseScript.Run(ctx, s.client, []string{redisInflightPrefix + key}, token).Err()
+}
+
+// parseAttempts 解析Redis哈希中的失败记录
+func parseAttempts(fields map[string]string) Attempts {
+	var a Attempts
+	a.Failures, _ = strconv.Atoi(fields["f"])
+	if ms, err := strconv.ParseInt(fields["l"], 10, 64); err == nil {
+		a.Last = time.UnixMilli(ms)
+	}
+	if ms, err := strconv.ParseInt(fields["u"], 10, 64); err == nil {
+		a.LockedUntil = time.UnixMilli(ms)
+	}
+	return a
+}
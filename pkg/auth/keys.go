@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"FastGin/pkg/config"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minHMACSecretLen HS256密钥的最小长度，与SHA-256输出长度一致
+const minHMACSecretLen = 32
+
+// key 单个签名密钥，signKey为空时仅用于验证
+type key struct {
+	kid       string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// loadKey 根据配置加载密钥
+func loadKey(cfg config.JWTKeyConfig) (*key, error) {
+	if cfg.KID == "" {
+		return nil, fmt.Errorf("auth: 密钥缺少kid")
+	}
+
+	k := &key{kid: cfg.KID}
+	switch cfg.Alg {
+	case "HS256":
+		if cfg.Secret == "" {
+			return nil, fmt.Errorf("auth: 密钥 %s 缺少secret", cfg.KID)
+		}
+		if len(cfg.Secret) < minHMACSecretLen {
+			return nil, fmt.Errorf("auth: 密钥 %s 的secret不足%d字节", cfg.KID, minHMACSecretLen)
+		}
+		k.method = jwt.SigningMethodHS256
+		k.signKey = []byte(cfg.Secret)
+		k.verifyKey = k.signKey
+
+	case "RS256":
+		k.method = jwt.SigningMethodRS256
+		if cfg.PrivateKeyFile != "" {
+			data, err := os.ReadFile(cfg.PrivateKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("auth: 读取密钥 %s 的私钥失败: %w", cfg.KID, err)
+			}
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, fmt.Errorf("auth: 解析密钥 %s 的私钥失败: %w", cfg.KID, err)
+			}
+			k.signKey = private
+			k.verifyKey = &private.PublicKey
+		}
+		if cfg.PublicKeyFile != "" {
+			data, err := os.ReadFile(cfg.PublicKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("auth: 读取密钥 %s 的公钥失败: %w", cfg.KID, err)
+			}
+			public, err := jwt.ParseRSAPublicKeyFromPEM(data)
+			if err != nil {
+				return nil, fmt.Errorf("auth: 解析密钥 %s 的公钥失败: %w", cfg.KID, err)
+			}
+			if private, ok := k.signKey.(*rsa.PrivateKey); ok && !private.PublicKey.Equal(public) {
+				return nil, fmt.Errorf("auth: 密钥 %s 的公钥与私钥不匹配", cfg.KID)
+			}
+			k.verifyKey = public
+		}
+
+	case "EdDSA":
+		k.method = jwt.SigningMethodEdDSA
+		if cfg.PrivateKeyFile != "" {
+			data, err := os.ReadFile(cfg.PrivateKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("auth: 读取密钥 %s 的私钥失败: %w", cfg.KID, err)
+			}
+			private, err := jwt.ParseEdPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, fmt.Errorf("auth: 解析密钥 %s 的私钥失败: %w", cfg.KID, err)
+			}
+			k.signKey = private
+			k.verifyKey = private.(ed25519.PrivateKey).Public()
+		}
+		if cfg.PublicKeyFile != "" {
+			data, err := os.ReadFile(cfg.PublicKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("auth: 读取密钥 %s 的公钥失败: %w", cfg.KID, err)
+			}
+			public, err := jwt.ParseEdPublicKeyFromPEM(data)
+			if err != nil {
+				return nil, fmt.Errorf("auth: 解析密钥 %s 的公钥失败: %w", cfg.KID, err)
+			}
+			if private, ok := k.signKey.(crypto.Signer); ok && !public.(ed25519.PublicKey).Equal(private.Public()) {
+				return nil, fmt.Errorf("auth: 密钥 %s 的公钥与私钥不匹配", cfg.KID)
+			}
+			k.verifyKey = public
+		}
+
+	default:
+		return nil, fmt.Errorf("auth: 密钥 %s 使用了不支持的算法 %q", cfg.KID, cfg.Alg)
+	}
+
+	if k.verifyKey == nil {
+		return nil, fmt.Errorf("auth: 密钥 %s 需要配置私钥或公钥", cfg.KID)
+	}
+	return k, nil
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"FastGin/pkg/config"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RevocationStore 令牌吊销存储，记录已吊销的jti和令牌族，保留到对应令牌过期
+type RevocationStore interface {
+	// Revoke 吊销指定ID，until之后记录可以删除
+	Revoke(ctx context.Context, id string, until time.Time) error
+	// Revoked 判断指定ID是否已吊销
+	Revoked(ctx context.Context, id string) (bool, error)
+	// Consume 原子地吊销指定ID，返回调用前是否尚未吊销，用于刷新令牌的一次性使用
+	// until已过时不保留记录并返回true，调用方应传入令牌无法再通过验证的时间
+	Consume(ctx context.Context, id string, until time.Time) (bool, error)
+}
+
+// NewRevocationStore 根据配置创建吊销存储，redis存储使用传入的客户端
+func NewRevocationStore(cfg config.RevocationConfig, client *redis.Client) (RevocationStore, error) {
+	switch cfg.Store {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	case "redis":
+		if client == nil {
+			return nil, fmt.Errorf("auth: 令牌吊销存储需要配置 redis")
+		}
+		return NewRedisStore(client), nil
+	default:
+		return nil, fmt.Errorf("auth: 未知的令牌吊销存储 %q", cfg.Store)
+	}
+}
+
+// MemoryStore 进程内吊销存储，仅适用于单实例部署
+type MemoryStore struct {
+	mu        sync.Mutex
+	revoked   map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryStore 创建进程内吊销存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{revoked: make(map[string]time.Time)}
+}
+
+// Revoke 吊销指定ID
+func (s *MemoryStore) Revoke(_ context.Context, id string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep()
+	if until.After(s.revoked[id]) {
+		s.revoked[id] = until
+	}
+	return nil
+}
+
+// Revoked 判断指定ID是否已吊销
+func (s *MemoryStore) Revoked(_ context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	until, ok := s.revoked[id]
+	return ok && time.Now().Before(until), nil
+}
+
+// Consume 原子地吊销指定ID
+func (s *MemoryStore) Consume(_ context.Context, id string, until time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep()
+	if existing, ok := s.revoked[id]; ok && time.Now().Before(existing) {
+		return false, nil
+	}
+	s.revoked[id] = until
+	return true, nil
+}
+
+// sweep 每分钟最多清理一次过期记录，调用方需持有锁
+func (s *MemoryStore) sweep() {
+	now := time.Now()
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for id, until := range s.revoked {
+		if now.After(until) {
+			delete(s.revoked, id)
+		}
+	}
+}
+
+// redisKeyPrefix Redis中吊销记录的键前缀
+const redisKeyPrefix = "auth:revoked:"
+
+// RedisStore 基于Redis协议的吊销存储，记录随令牌过期自动删除
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore 使用已有的Redis客户端创建吊销存储
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Revoke 吊销指定ID
+func (s *RedisStore) Revoke(ctx context.Context, id string, until time.Time) error {
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return nil
+	}
+	return s.client.Set(ctx, redisKeyPrefix+id, 1, ttl).Err()
+}
+
+// Revoked 判断指定ID是否已吊销
+func (s *RedisStore) Revoked(ctx context.Context, id string) (bool, error) {
+	n, err := s.client.Exists(ctx, redisKeyPrefix+id).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Consume 通过SET NX原子地吊销指定ID
+func (s *RedisStore) Consume(ctx context.Context, id string, until time.Time) (bool, error) {
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		// 与MemoryStore一致：记录已无需保留，令牌验证阶段会拒绝，不视为重复使用
+		return true, nil
+	}
+	return s.client.SetNX(ctx, redisKeyPrefix+id, 1, ttl).Result()
+}
@@ -0,0 +1,136 @@
+package bind
+
+import (
+	"FastGin/pkg/errcode"
+	"FastGin/pkg/i18n"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError 字段级校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+var initOnce sync.Once
+
+// Init 初始化校验器：字段名使用json标签，并注册内置的自定义校验规则
+// 应在启动阶段调用，重复调用无副作用
+func Init() {
+	initOnce.Do(func() {
+		v := engine()
+		v.RegisterTagNameFunc(fieldName)
+		for tag, fn := range builtinValidators {
+			if err := v.RegisterValidation(tag, fn); err != nil {
+				panic(err)
+			}
+		}
+	})
+}
+
+// RegisterValidation 注册自定义校验规则
+func RegisterValidation(tag string, fn validator.Func) error {
+	return engine().RegisterValidation(tag, fn)
+}
+
+// engine 获取gin使用的校验器实例
+func engine() *validator.Validate {
+	return binding.Validator.Engine().(*validator.Validate)
+}
+
+// fieldName 依次取json、form、uri标签作为字段名，均不存在时使用结构体字段名
+func fieldName(f reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri"} {
+		name := strings.SplitN(f.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+// Bind 一次性绑定URI参数、查询参数、表单和JSON请求体，全部填充后再统一校验
+// 绑定或校验失败时返回携带字段级错误详情的参数错误
+func Bind(c *gin.Context, obj interface{}) error {
+	if err := bindAll(c, obj); err != nil {
+		return errcode.InvalidParams.Wrap(err)
+	}
+
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		return errcode.InvalidParams.WithDetails(Errors(c, err)).Wrap(err)
+	}
+	return nil
+}
+
+// bindAll 按查询参数、请求体、URI参数的顺序填充，后者覆盖前者
+func bindAll(c *gin.Context, obj interface{}) error {
+	if query := c.Request.URL.Query(); len(query) > 0 {
+		if err := binding.MapFormWithTag(obj, query, "form"); err != nil {
+			return err
+		}
+	}
+
+	if c.Request.Body != nil && c.Request.Method != http.MethodGet {
+		switch c.ContentType() {
+		case binding.MIMEPOSTForm, binding.MIMEMultipartPOSTForm:
+			if err := c.Request.ParseMultipartForm(32 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+				return err
+			}
+			if err := binding.MapFormWithTag(obj, c.Request.PostForm, "form"); err != nil {
+				return err
+			}
+		default:
+			// 与 ShouldBindJSON 一致，非表单请求体按JSON解析，兼容未设置 Content-Type 的客户端
+			if err := json.NewDecoder(c.Request.Body).Decode(obj); err != nil && !errors.Is(err, io.EOF) {
+				return err
+			}
+		}
+	}
+
+	if len(c.Params) > 0 {
+		params := make(map[string][]string, len(c.Params))
+		for _, p := range c.Params {
+			params[p.Key] = []string{p.Value}
+		}
+		if err := binding.MapFormWithTag(obj, params, "uri"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Errors 将校验错误转换为字段级错误列表，提示信息按请求语言翻译
+// 非校验错误时返回nil
+func Errors(c *gin.Context, err error) []FieldError {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return nil
+	}
+
+	locale := i18n.Locale(c)
+	list := make([]FieldError, 0, len(errs))
+	for _, fe := range errs {
+		list = append(list, FieldError{
+			Field:   fe.Field(),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: i18n.FieldMessage(locale, fe),
+		})
+	}
+	return list
+}
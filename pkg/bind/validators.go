@@ -0,0 +1,66 @@
+package bind
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// builtinValidators 内置自定义校验规则，标签名即binding标签中使用的规则名
+var builtinValidators = map[string]validator.Func{
+	"phone":           validatePhone,
+	"idcard":          validateIDCard,
+	"strong_password": validateStrongPassword,
+}
+
+var phonePattern = regexp.MustCompile(`^1[3-9]\d{9}$`)
+
+// validatePhone 校验中国大陆手机号
+func validatePhone(fl validator.FieldLevel) bool {
+	return phonePattern.MatchString(fl.Field().String())
+}
+
+var (
+	idCardWeights = []int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	idCardChecks  = "10X98765432"
+	idCardPattern = regexp.MustCompile(`^\d{17}[\dXx]$`)
+)
+
+// validateIDCard 校验18位居民身份证号码，包含校验位计算
+func validateIDCard(fl validator.FieldLevel) bool {
+	id := fl.Field().String()
+	if !idCardPattern.MatchString(id) {
+		return false
+	}
+
+	sum := 0
+	for i, w := range idCardWeights {
+		sum += int(id[i]-'0') * w
+	}
+	return idCardChecks[sum%11] == strings.ToUpper(id[17:])[0]
+}
+
+// validateStrongPassword 校验强密码：至少8位，包含大小写字母、数字和特殊字符
+func validateStrongPassword(fl validator.FieldLevel) bool {
+	password := fl.Field().String()
+	if len(password) < 8 {
+		return false
+	}
+
+	var upper, lower, digit, special bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			special = true
+		}
+	}
+	return upper && lower && digit && special
+}
@@ -0,0 +1,15 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Store 键值缓存存储，值为不透明字节串，ttl<=0表示不过期
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	// DeletePrefix 删除指定前缀的全部键，返回删除的数量
+	DeletePrefix(ctx context.Context, prefix string) (int, error)
+}
@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLRUSize 内存LRU默认最大条目数
+const DefaultLRUSize = 1024
+
+// LRU 内存LRU缓存，超出容量时淘汰最久未使用的条目
+type LRU struct {
+	size int
+
+	mu    sync.Mutex
+	items map[string]*list.Element
+	order *list.List
+}
+
+type lruItem struct {
+	key      string
+	value    []byte
+	expireAt time.Time
+}
+
+// NewLRU 创建内存LRU缓存，size<=0时使用默认容量
+func NewLRU(size int) *LRU {
+	if size <= 0 {
+		size = DefaultLRUSize
+	}
+	return &LRU{
+		size:  size,
+		items: make(map[string]*list.Element),
+		order: list.New(),
+	}
+}
+
+// Get 读取缓存，过期条目视为不存在
+func (c *LRU) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	item := el.Value.(*lruItem)
+	if !item.expireAt.IsZero() && time.Now().After(item.expireAt) {
+		c.remove(el)
+		return nil, false, nil
+	}
+	c.order.MoveToFront(el)
+	return item.value, true, nil
+}
+
+// Set 写入缓存
+func (c *LRU) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expireAt time.Time
+	if ttl > 0 {
+		expireAt = time.Now().Add(ttl)
+	}
+
+	if el, ok := c.items[key]; ok {
+		item := el.Value.(*lruItem)
+		item.value = value
+		item.expireAt = expireAt
+		c.order.MoveToFront(el)
+		return nil
+	}
+
+	c.items[key] = c.order.PushFront(&lruItem{key: key, value: value, expireAt: expireAt})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+// Delete 删除指定键
+func (c *LRU) Delete(_ context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := c.items[key]; ok {
+			c.remove(el)
+		}
+	}
+	return nil
+}
+
+// DeletePrefix 删除指定前缀的全部键
+func (c *LRU) DeletePrefix(_ context.Context, prefix string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := 0
+	for key, el := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(el)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Len 返回当前条目数，包括尚未清理的过期条目
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// remove 删除条目，调用方需持有锁
+func (c *LRU) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*lruItem).key)
+}
@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"FastGin/pkg/config"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NewRedisClient 根据配置创建Redis客户端，连接在首次使用时建立
+func NewRedisClient(cfg config.RedisConfig) (*redis.Client, error) {
+	if cfg.Addr == "" {
+		return nil, fmt.Errorf("cache: 未配置 redis.addr")
+	}
+	return redis.NewClient(&redis.Options{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+		PoolSize: cfg.PoolSize,
+	}), nil
+}
+
+// Redis 基于Redis协议的缓存存储，兼容Redis、KeyDB、Dragonfly等服务
+type Redis struct {
+	client *redis.Client
+}
+
+// NewRedis 使用已有的Redis客户端创建缓存存储
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{client: client}
+}
+
+// Get 读取缓存
+func (s *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set 写入缓存
+func (s *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl < 0 {
+		ttl = 0
+	}
+	return s.client.Set(ctx, key, value, ttl).Err()
+}
+
+// Delete 删除指定键
+func (s *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return s.client.Del(ctx, keys...).Err()
+}
+
+// DeletePrefix 通过SCAN遍历并删除指定前缀的键，避免KEYS阻塞服务
+func (s *Redis) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	n := 0
+	iter := s.client.Scan(ctx, 0, escapePattern(prefix)+"*", 500).Iterator()
+	batch := make([]string, 0, 500)
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == cap(batch) {
+			if err := s.client.Unlink(ctx, batch...).Err(); err != nil {
+				return n, err
+			}
+			n += len(batch)
+			batch = batch[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return n, err
+	}
+	if len(batch) > 0 {
+		if err := s.client.Unlink(ctx, batch...).Err(); err != nil {
+			return n, err
+		}
+		n += len(batch)
+	}
+	return n, nil
+}
+
+// escapePattern 转义SCAN匹配模式中的通配符
+func escapePattern(s string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '*', '?', '[', ']', '\\':
+			b = append(b, '\\')
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 )
 
 // Log 日志配置
@@ -21,7 +22,311 @@ type DBConfig struct {
 	Password string `yaml:"password"`
 }
 
+// UpstreamConfig 上游服务HTTP客户端配置
+type UpstreamConfig struct {
+	BaseURL             string            `yaml:"base_url"`
+	Timeout             time.Duration     `yaml:"timeout"`               // 整个请求的超时时间
+	DialTimeout         time.Duration     `yaml:"dial_timeout"`          // 建立连接超时
+	TLSHandshakeTimeout time.Duration     `yaml:"tls_handshake_timeout"` // TLS握手超时
+	ForceHTTP2          bool              `yaml:"force_http2"`
+	Proxy               string            `yaml:"proxy"` // 代理地址，如 http://127.0.0.1:7890
+	TLS                 TLSConfig         `yaml:"tls"`
+	Headers             map[string]string `yaml:"headers"`    // 默认请求头
+	CookieJar           bool              `yaml:"cookie_jar"` // 是否启用客户端共享的Cookie管理，使用按账号会话时应关闭
+	Budget              time.Duration     `yaml:"budget"`     // 单次调用（含重试）的总耗时上限
+	Retry               RetryConfig       `yaml:"retry"`
+	Breaker             BreakerConfig     `yaml:"breaker"`
+	Sign                SignConfig        `yaml:"sign"`
+	Cassette            CassetteConfig    `yaml:"cassette"`
+	Session             SessionConfig     `yaml:"session"`
+	Profile             string            `yaml:"profile"` // 客户端指纹：okhttp4、chrome、safari、firefox，为空使用Go默认握手
+	Debug               DebugConfig       `yaml:"debug"`
+	Cache               CacheConfig       `yaml:"cache"`
+	UseProxyPool        bool              `yaml:"use_proxy_pool"` // 是否通过出口代理池发送请求，启用后忽略proxy
+}
+
+// DebugConfig 上游请求调试日志配置，运行时可通过管理接口切换
+type DebugConfig struct {
+	Enabled     bool     `yaml:"enabled"`      // 通过日志记录每次请求的摘要和耗时
+	Dump        bool     `yaml:"dump"`         // 调试模式，同时将完整请求和响应写入滚动文件
+	DumpDir     string   `yaml:"dump_dir"`     // 转储文件目录，默认 logs
+	BodyLimit   int      `yaml:"body_limit"`   // 摘要日志中请求体和响应体的截断长度
+	MaskHeaders []string `yaml:"mask_headers"` // 在默认脱敏列表基础上追加
+	MaskParams  []string `yaml:"mask_params"`
+}
+
+// CacheConfig 上游响应缓存配置，仅缓存成功的幂等查询
+type CacheConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Backend   string        `yaml:"backend"`    // memory（默认）或 redis，redis 使用顶层 redis 配置
+	Size      int           `yaml:"size"`       // 内存LRU最大条目数
+	TTL       time.Duration `yaml:"ttl"`        // 默认缓存时长，响应带有 Cache-Control: max-age 时以其为准
+	StaleTTL  time.Duration `yaml:"stale_ttl"`  // 过期后保留用于 ETag/Last-Modified 重新验证的时长
+	KeyParams []string      `yaml:"key_params"` // 参与缓存键的参数，为空时使用全部参数（签名参数除外）
+	Rules     []CacheRule   `yaml:"rules"`      // 按接口配置，为空时缓存全部GET请求
+}
+
+// CacheRule 单个接口的缓存规则
+type CacheRule struct {
+	Method    string        `yaml:"method"` // 默认 GET
+	Path      string        `yaml:"path"`   // 精确匹配，以 * 结尾时按前缀匹配
+	TTL       time.Duration `yaml:"ttl"`    // 非零时覆盖响应中的 max-age
+	KeyParams []string      `yaml:"key_params"`
+}
+
+// AuthConfig JWT认证配置
+type AuthConfig struct {
+	Issuer     string           `yaml:"issuer"`
+	Audience   string           `yaml:"audience"`
+	AccessTTL  time.Duration    `yaml:"access_ttl"`  // 访问令牌有效期，默认15分钟
+	RefreshTTL time.Duration    `yaml:"refresh_ttl"` // 刷新令牌有效期，默认7天
+	Leeway     time.Duration    `yaml:"leeway"`      // 校验时间时允许的时钟偏差
+	ActiveKey  string           `yaml:"active_key"`  // 签发令牌使用的kid，为空时使用第一个可签名的密钥
+	Keys       []JWTKeyConfig   `yaml:"keys"`        // 全部密钥都可用于验证，轮换时保留旧密钥直到其签发的令牌过期
+	Revocation RevocationConfig `yaml:"revocation"`
+	APIKeys    APIKeysConfig    `yaml:"api_keys"`
+	LoginGuard LoginGuardConfig `yaml:"login_guard"`
+	Users      []UserConfig     `yaml:"users"` // 本地登录账号，未配置时登录接口拒绝全部请求
+}
+
+// UserConfig 本地登录账号
+type UserConfig struct {
+	Username     string `yaml:"username"`
+	PasswordHash string `yaml:"password_hash"` // bcrypt摘要，如 htpasswd -nbBC 10 "" <密码> | tr -d ':\n'
+}
+
+// LoginGuardConfig 登录失败保护配置，按用户名和来源IP分别统计失败次数
+type LoginGuardConfig struct {
+	Store         string        `yaml:"store"`           // memory（默认）或 redis，多实例部署应使用 redis
+	Window        time.Duration `yaml:"window"`          // 统计窗口，最后一次失败后超过该时长计数清零，默认15分钟
+	DelayAfter    int           `yaml:"delay_after"`     // 同一用户名失败N次后开始要求等待，默认3
+	BaseDelay     time.Duration `yaml:"base_delay"`      // 首次等待时长，之后每次失败翻倍，默认1秒
+	MaxDelay      time.Duration `yaml:"max_delay"`       // 最长等待时长，默认30秒
+	UserLockAfter int           `yaml:"user_lock_after"` // 同一用户名失败N次后临时锁定，默认5
+	IPLockAfter   int           `yaml:"ip_lock_after"`   // 同一IP失败N次后临时锁定，默认20
+	LockDuration  time.Duration `yaml:"lock_duration"`   // 锁定时长，默认15分钟
+	CaptchaAfter  int           `yaml:"captcha_after"`   // 同一用户名失败N次后在错误响应中要求验证码，0表示不启用
+}
+
+// APIKeysConfig 服务间调用的API密钥配置
+type APIKeysConfig struct {
+	Store         string         `yaml:"store"`          // memory（默认）或 redis，通过管理接口创建的密钥保存在这里
+	TouchInterval time.Duration  `yaml:"touch_interval"` // 最近使用时间的写入间隔，默认1分钟
+	Keys          []APIKeyConfig `yaml:"keys"`           // 启动时预置的密钥，按ID同步到存储，从配置中删除后自动吊销
+}
+
+// APIKeyConfig 预置的API密钥，只保存密钥的SHA-256摘要
+type APIKeyConfig struct {
+	ID        string          `yaml:"id"`
+	Name      string          `yaml:"name"`
+	Hash      string          `yaml:"hash"`       // 完整密钥的SHA-256十六进制摘要
+	Scopes    []APIKeyScope   `yaml:"scopes"`     // 允许访问的路由组，未配置时拒绝全部请求
+	AllowIPs  []string        `yaml:"allow_ips"`  // 来源IP或CIDR白名单，为空时不限制
+	RateLimit APIKeyRateLimit `yaml:"rate_limit"` // 单个密钥的限流，未配置时不限制
+	ExpiresAt time.Time       `yaml:"expires_at"` // 过期时间，为空时永不过期
+}
+
+// APIKeyScope API密钥的访问范围
+type APIKeyScope struct {
+	Group   string   `yaml:"group" json:"group"`               // 路由组前缀，如 /admin，"/" 表示全部路由
+	Methods []string `yaml:"methods" json:"methods,omitempty"` // 允许的请求方法，为空时不限制
+}
+
+// APIKeyRateLimit API密钥限流配置
+type APIKeyRateLimit struct {
+	RPS   float64 `yaml:"rps" json:"rps"`     // 每秒请求数，<=0 表示不限制
+	Burst int     `yaml:"burst" json:"burst"` // 突发请求数，默认与rps相同
+}
+
+// RevocationConfig 令牌吊销配置
+type RevocationConfig struct {
+	Store     string        `yaml:"store"`      // memory（默认）或 redis，多实例部署应使用 redis
+	CacheTTL  time.Duration `yaml:"cache_ttl"`  // 本地缓存“未吊销”结果的时长，默认5秒
+	CacheSize int           `yaml:"cache_size"` // 本地缓存最大条目数
+}
+
+// JWTKeyConfig JWT密钥配置
+type JWTKeyConfig struct {
+	KID            string `yaml:"kid"`
+	Alg            string `yaml:"alg"`              // HS256、RS256、EdDSA
+	Secret         string `yaml:"secret"`           // HS256 密钥
+	PrivateKeyFile string `yaml:"private_key_file"` // RS256/EdDSA 私钥（PEM），仅用于验证的密钥可省略
+	PublicKeyFile  string `yaml:"public_key_file"`  // RS256/EdDSA 公钥（PEM），为空时由私钥推导
+}
+
+// RBACConfig 基于角色的访问控制配置，未授予的权限一律拒绝
+type RBACConfig struct {
+	Roles    []RoleConfig  `yaml:"roles"`
+	Bindings []RoleBinding `yaml:"bindings"`
+}
+
+// RoleConfig 角色定义
+type RoleConfig struct {
+	Name        string   `yaml:"name" json:"name"`
+	Permissions []string `yaml:"permissions" json:"permissions"`     // 权限按冒号分段，* 匹配单段，末尾的 * 匹配其余全部分段
+	Inherits    []string `yaml:"inherits" json:"inherits,omitempty"` // 继承其他角色的全部权限
+}
+
+// RoleBinding 将角色授予认证主体
+type RoleBinding struct {
+	Subject string   `yaml:"subject" json:"subject"` // 用户为 user:<subject>，API密钥为 key:<id>，合作方为 partner:<id>；末尾的 * 按前缀匹配，如 key:*
+	Roles   []string `yaml:"roles" json:"roles"`
+}
+
+// WebhookConfig 合作方回调签名验证配置
+type WebhookConfig struct {
+	Skew       time.Duration   `yaml:"skew"`        // 允许的时间戳偏差，默认5分钟
+	NonceStore string          `yaml:"nonce_store"` // memory（默认）或 redis，多实例部署应使用 redis
+	Partners   []PartnerConfig `yaml:"partners"`
+}
+
+// PartnerConfig 合作方配置
+type PartnerConfig struct {
+	ID      string   `yaml:"id"`
+	Secrets []string `yaml:"secrets"` // 任一密钥验证通过即可，轮换时同时配置新旧密钥
+}
+
+// TracingConfig OpenTelemetry链路追踪配置
+type TracingConfig struct {
+	Enabled     bool              `yaml:"enabled"`
+	ServiceName string            `yaml:"service_name"` // 默认 fastgin
+	Exporter    string            `yaml:"exporter"`     // otlp-http（默认）、otlp-grpc 或 stdout
+	Endpoint    string            `yaml:"endpoint"`     // OTLP接收地址，如 localhost:4318，为空时读取 OTEL_EXPORTER_OTLP_ENDPOINT
+	Insecure    bool              `yaml:"insecure"`     // 不使用TLS连接OTLP接收端
+	Headers     map[string]string `yaml:"headers"`      // 发送到OTLP接收端的请求头，如鉴权令牌
+	SampleRatio *float64          `yaml:"sample_ratio"` // 新链路的采样比例，0~1，未配置时为1；上游已决定采样时跟随上游
+}
+
+// HealthConfig 健康检查配置
+type HealthConfig struct {
+	Timeout           time.Duration `yaml:"timeout"`            // 单项检查的超时，默认2秒
+	CacheTTL          time.Duration `yaml:"cache_ttl"`          // 检查结果的缓存时间，默认5秒
+	MinFreeDiskMB     uint64        `yaml:"min_free_disk_mb"`   // 日志目录所在磁盘的最小剩余空间，0表示不检查
+	CriticalUpstreams []string      `yaml:"critical_upstreams"` // 不可达时就绪检查失败的上游，其余上游只在详情中展示
+	ShutdownDelay     time.Duration `yaml:"shutdown_delay"`     // 收到退出信号后就绪检查先失败，等待该时长再关闭服务
+}
+
+// MetricsConfig Prometheus指标配置
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Path    string `yaml:"path"` // 抓取路径，默认 /metrics
+	Addr    string `yaml:"addr"` // 独立的监听地址，如 :9090，为空时挂在主端口上
+}
+
+// SecurityHeadersConfig 安全响应头配置，字段为空时不发送对应的响应头
+type SecurityHeadersConfig struct {
+	HSTS               HSTSConfig `yaml:"hsts"`
+	ContentTypeNosniff bool       `yaml:"content_type_nosniff"` // 发送 X-Content-Type-Options: nosniff
+	FrameOptions       string     `yaml:"frame_options"`        // X-Frame-Options，DENY 或 SAMEORIGIN
+	ReferrerPolicy     string     `yaml:"referrer_policy"`
+	PermissionsPolicy  string     `yaml:"permissions_policy"`
+	CSP                CSPConfig  `yaml:"csp"`
+}
+
+// HSTSConfig Strict-Transport-Security配置，MaxAge为0时不发送
+type HSTSConfig struct {
+	MaxAge            time.Duration `yaml:"max_age"`
+	IncludeSubdomains bool          `yaml:"include_subdomains"`
+	Preload           bool          `yaml:"preload"`
+}
+
+// CSPConfig Content-Security-Policy配置
+type CSPConfig struct {
+	Policy     string `yaml:"policy"`      // 策略中的 {nonce} 替换为每个请求生成的随机数
+	ReportOnly bool   `yaml:"report_only"` // 只上报违规不拦截，使用 Content-Security-Policy-Report-Only
+	ReportURI  string `yaml:"report_uri"`  // 违规报告的接收地址，同时通过 report-uri 和 report-to 声明
+}
+
+// RedisConfig Redis连接配置
+type RedisConfig struct {
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+	PoolSize int    `yaml:"pool_size"`
+}
+
+// ProxyPoolConfig 出口代理池配置
+type ProxyPoolConfig struct {
+	Strategy      string        `yaml:"strategy"`       // 选择策略：round_robin、least_errors、sticky，默认 round_robin
+	ProbeURL      string        `yaml:"probe_url"`      // 健康检查地址，为空则不做主动检查
+	ProbeInterval time.Duration `yaml:"probe_interval"` // 健康检查间隔
+	ProbeTimeout  time.Duration `yaml:"probe_timeout"`  // 健康检查超时
+	MaxFailures   int           `yaml:"max_failures"`   // 连续失败多少次后隔离
+	Quarantine    time.Duration `yaml:"quarantine"`     // 隔离时长
+	MaxSticky     int           `yaml:"max_sticky"`     // sticky策略保留的最大账号数，超出时淘汰最久未使用的账号，默认10000
+	Proxies       []ProxyConfig `yaml:"proxies"`
+}
+
+// ProxyConfig 单个代理配置
+type ProxyConfig struct {
+	URL      string `yaml:"url"` // 支持 http://、https://、socks5://
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// SessionConfig 按账号隔离的上游会话配置
+type SessionConfig struct {
+	Store       string        `yaml:"store"`        // 会话存储：memory、file，默认 memory
+	Dir         string        `yaml:"dir"`          // file存储的目录
+	TTL         time.Duration `yaml:"ttl"`          // 会话有效期，到期后在下次请求前重新登录，0表示不过期
+	MaxSessions int           `yaml:"max_sessions"` // 内存中保留的最大会话数，超出时淘汰最久未使用的会话，默认10000
+}
+
+// CassetteConfig 上游请求录制/回放配置，用于离线测试
+type CassetteConfig struct {
+	Mode         string   `yaml:"mode"`          // record：真实请求并录制；replay：仅回放，未匹配的请求直接失败；为空表示关闭
+	Path         string   `yaml:"path"`          // 录制文件路径
+	MaskHeaders  []string `yaml:"mask_headers"`  // 录制时需脱敏的请求头，追加到默认列表
+	MaskParams   []string `yaml:"mask_params"`   // 录制时需脱敏的参数，追加到默认列表
+	IgnoreParams []string `yaml:"ignore_params"` // 匹配时忽略取值的参数，签名相关参数会自动加入
+}
+
+// SignConfig 上游请求签名配置
+type SignConfig struct {
+	Type           string `yaml:"type"`            // 签名算法：hmac-sha256、md5-sorted，为空表示不签名
+	Secret         string `yaml:"secret"`          // 签名密钥
+	SignParam      string `yaml:"sign_param"`      // 签名参数名，默认 sign
+	TimestampParam string `yaml:"timestamp_param"` // 时间戳参数名，默认 t
+	TimestampUnit  string `yaml:"timestamp_unit"`  // 时间戳单位：ms、s，默认 ms
+	NonceParam     string `yaml:"nonce_param"`     // 随机串参数名，为空则不生成
+	Header         string `yaml:"header"`          // 非空时签名写入该请求头，否则写入请求参数
+}
+
+// RetryConfig 上游调用重试配置
+type RetryConfig struct {
+	MaxAttempts        int           `yaml:"max_attempts"`         // 最大尝试次数（含首次），小于等于1表示不重试
+	InitialInterval    time.Duration `yaml:"initial_interval"`     // 首次重试间隔，之后按指数增长
+	MaxInterval        time.Duration `yaml:"max_interval"`         // 重试间隔上限
+	Statuses           []int         `yaml:"statuses"`             // 需要重试的响应状态码
+	RetryNonIdempotent bool          `yaml:"retry_non_idempotent"` // 是否重试非幂等请求
+}
+
+// BreakerConfig 熔断器配置，按上游主机分别统计
+type BreakerConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	FailureThreshold int           `yaml:"failure_threshold"`  // 连续失败多少次后熔断
+	OpenTimeout      time.Duration `yaml:"open_timeout"`       // 熔断持续时间，到期后进入半开状态
+	HalfOpenRequests int           `yaml:"half_open_requests"` // 半开状态允许的探测请求数
+}
+
+// TLSConfig 客户端TLS配置
+type TLSConfig struct {
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+	ServerName         string `yaml:"server_name"`
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+}
+
 // Addr 获取服务器地址
 func (s *Server) Addr() string {
 	return fmt.Sprintf(":%d", s.Port)
 }
+
+// ScrapePath 获取指标抓取路径，未配置时为 /metrics
+func (m *MetricsConfig) ScrapePath() string {
+	if m.Path == "" {
+		return "/metrics"
+	}
+	return m.Path
+}
@@ -4,15 +4,26 @@ import "flag"
 
 // Config 应用配置结构
 type Config struct {
-	DB     DBConfig  `yaml:"db"`
-	Server Server    `yaml:"server"`
-	Log    LogConfig `yaml:"log"`
+	DB              DBConfig                  `yaml:"db"`
+	Server          Server                    `yaml:"server"`
+	Log             LogConfig                 `yaml:"log"`
+	Upstreams       map[string]UpstreamConfig `yaml:"upstreams"`
+	ProxyPool       ProxyPoolConfig           `yaml:"proxy_pool"`
+	Redis           RedisConfig               `yaml:"redis"`
+	Auth            AuthConfig                `yaml:"auth"`
+	RBAC            RBACConfig                `yaml:"rbac"`
+	Webhooks        WebhookConfig             `yaml:"webhooks"`
+	SecurityHeaders SecurityHeadersConfig     `yaml:"security_headers"`
+	Metrics         MetricsConfig             `yaml:"metrics"`
+	Tracing         TracingConfig             `yaml:"tracing"`
+	Health          HealthConfig              `yaml:"health"`
 }
 
 // Server 服务器配置
 type Server struct {
-	Port int    `yaml:"port"`
-	Mode string `yaml:"mode"`
+	Port           int      `yaml:"port"`
+	Mode           string   `yaml:"mode"`
+	TrustedProxies []string `yaml:"trusted_proxies"` // 可信反向代理的IP或CIDR，为空时不信任任何代理，客户端IP取连接地址
 }
 
 type FlagOptions struct {
@@ -1,69 +1,104 @@
 package errcode
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
-
-	"github.com/gin-gonic/gin"
 )
 
-// ErrorResponse 定义错误结构
-type ErrorResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+// AppError 业务错误，实现error接口，可配合errors.Is/As使用
+// Code 为业务错误码，Status 为HTTP状态码，Key 为国际化消息键
+type AppError struct {
+	Code    int         `json:"code"`
+	Status  int         `json:"-"`
+	Key     string      `json:"-"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+	cause   error
 }
 
-// NewError 创建新的错误
-func NewError(code int, msg string) *ErrorResponse {
-	return &ErrorResponse{
-		Code:    code,
-		Message: msg,
+// Error 实现error接口
+func (e *AppError) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("[%d] %s: %v", e.Code, e.Message, e.cause)
 	}
+	return fmt.Sprintf("[%d] %s", e.Code, e.Message)
 }
 
-// 预定义错误
-var (
-	SuccessResponse     = NewError(200, "成功")
-	InternalServerError = NewError(500, "服务内部错误")
-	InvalidParams       = NewError(400, "参数错误")
-	TooManyRequests     = NewError(429, "请求过多")
-)
+// Unwrap 返回被包装的原始错误
+func (e *AppError) Unwrap() error {
+	return e.cause
+}
 
-// Response 统一响应结构
-type Response struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data"`
+// Is 业务错误码相同即视为同一错误
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && t.Code == e.Code
 }
 
-// Success 成功响应
-func Success(c *gin.Context, data interface{}) {
-	c.JSON(http.StatusOK, Response{
-		Code:    SuccessResponse.Code,
-		Message: SuccessResponse.Message,
-		Data:    data,
-	})
+// clone 复制错误，保证预定义错误不被修改
+func (e *AppError) clone() *AppError {
+	cp := *e
+	return &cp
 }
 
-// SendError 错误响应
-func SendError(c *gin.Context, err *ErrorResponse) {
-	c.JSON(http.StatusOK, Response{
-		Code:    err.Code,
-		Message: err.Message,
-		Data:    nil,
-	})
+// WithMessage 返回替换提示信息后的错误副本
+// 自定义提示不再参与国际化翻译
+func (e *AppError) WithMessage(msg string) *AppError {
+	cp := e.clone()
+	cp.Message = msg
+	cp.Key = ""
+	return cp
 }
 
-// ParamError 参数错误响应
-func ParamError(c *gin.Context) {
-	SendError(c, InvalidParams)
+// WithDetails 返回附带详情的错误副本
+func (e *AppError) WithDetails(details interface{}) *AppError {
+	cp := e.clone()
+	cp.Details = details
+	return cp
 }
 
-// ServerErrorResponse 服务器错误响应
-func ServerErrorResponse(c *gin.Context) {
-	SendError(c, InternalServerError)
+// Wrap 返回包装原始错误的副本
+func (e *AppError) Wrap(cause error) *AppError {
+	cp := e.clone()
+	cp.cause = cause
+	return cp
 }
 
-// 参数验证错误
-func Error(c *gin.Context, errcode int, msg string) {
-	SendError(c, InvalidParams)
+// As 将任意错误转换为AppError，无法识别的错误视为服务内部错误
+func As(err error) *AppError {
+	if err == nil {
+		return nil
+	}
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return InternalServerError.Wrap(err)
 }
+
+// 预定义错误
+var (
+	InternalServerError = Register(10000, http.StatusInternalServerError, "error.internal", "服务内部错误")
+	InvalidParams       = Register(10001, http.StatusBadRequest, "error.invalid_params", "参数错误")
+	Unauthorized        = Register(10002, http.StatusUnauthorized, "error.unauthorized", "未授权")
+	Forbidden           = Register(10003, http.StatusForbidden, "error.forbidden", "禁止访问")
+	NotFound            = Register(10004, http.StatusNotFound, "error.not_found", "资源不存在")
+	TooManyRequests     = Register(10005, http.StatusTooManyRequests, "error.too_many_requests", "请求太频繁，请稍后再试")
+	UpstreamUnavailable = Register(10006, http.StatusServiceUnavailable, "error.upstream_unavailable", "上游服务不可用")
+	UpstreamTimeout     = Register(10007, http.StatusGatewayTimeout, "error.upstream_timeout", "上游服务响应超时")
+	UpstreamRejected    = Register(10008, http.StatusUnprocessableEntity, "error.upstream_rejected", "上游服务拒绝了请求")
+	UpstreamBadGateway  = Register(10009, http.StatusBadGateway, "error.upstream_bad_gateway", "上游服务异常")
+	TokenInvalid        = Register(10010, http.StatusUnauthorized, "error.token_invalid", "令牌无效")
+	TokenExpired        = Register(10011, http.StatusUnauthorized, "error.token_expired", "令牌已过期")
+	TokenRevoked        = Register(10012, http.StatusUnauthorized, "error.token_revoked", "令牌已失效")
+	APIKeyInvalid       = Register(10013, http.StatusUnauthorized, "error.api_key_invalid", "API密钥无效")
+	APIKeyExpired       = Register(10014, http.StatusUnauthorized, "error.api_key_expired", "API密钥已过期")
+	APIKeyRevoked       = Register(10015, http.StatusUnauthorized, "error.api_key_revoked", "API密钥已吊销")
+	SignatureInvalid    = Register(10016, http.StatusUnauthorized, "error.signature_invalid", "签名无效")
+	SignatureExpired    = Register(10017, http.StatusUnauthorized, "error.signature_expired", "请求时间戳超出允许范围")
+	RequestReplayed     = Register(10018, http.StatusConflict, "error.request_replayed", "重复的请求")
+	LoginLocked         = Register(10019, http.StatusTooManyRequests, "error.login_locked", "登录失败次数过多，账户已临时锁定")
+	LoginThrottled      = Register(10020, http.StatusTooManyRequests, "error.login_throttled", "登录尝试过于频繁，请稍后再试")
+	NotReady            = Register(10021, http.StatusServiceUnavailable, "error.not_ready", "服务未就绪")
+)
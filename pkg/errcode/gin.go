@@ -0,0 +1,69 @@
+package errcode
+
+import (
+	"FastGin/pkg/i18n"
+	"FastGin/pkg/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HandlerFunc 可返回错误的处理函数
+type HandlerFunc func(c *gin.Context) error
+
+// Handle 将返回错误的处理函数适配为gin.HandlerFunc
+// 返回的错误会记录到c.Errors，由错误处理中间件统一输出
+func Handle(fn HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := fn(c); err != nil {
+			c.Error(err)
+			c.Abort()
+		}
+	}
+}
+
+// Success 成功响应
+func Success(c *gin.Context, data interface{}) {
+	response.OK(c, data)
+}
+
+// SendError 错误响应，按AppError中的HTTP状态码输出，提示信息按请求语言翻译
+func SendError(c *gin.Context, err error) {
+	appErr := As(err)
+	status := appErr.Status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	response.FailWithData(c, status, appErr.Code, Message(c, appErr), appErr.Details)
+}
+
+// Message 按当前请求语言解析错误提示
+func Message(c *gin.Context, err *AppError) string {
+	if err.Key == "" {
+		return err.Message
+	}
+	return i18n.Translate(i18n.Locale(c), err.Key, err.Message, nil)
+}
+
+// ParamError 参数错误响应
+func ParamError(c *gin.Context) {
+	SendError(c, InvalidParams)
+}
+
+// ServerErrorResponse 服务器错误响应
+func ServerErrorResponse(c *gin.Context) {
+	SendError(c, InternalServerError)
+}
+
+// Error 按错误码输出错误响应，msg非空时覆盖默认提示
+// 未注册的错误码按参数错误处理
+func Error(c *gin.Context, code int, msg string) {
+	appErr, ok := Lookup(code)
+	if !ok {
+		appErr = &AppError{Code: code, Status: InvalidParams.Status, Message: InvalidParams.Message}
+	}
+	if msg != "" {
+		appErr = appErr.WithMessage(msg)
+	}
+	SendError(c, appErr)
+}
@@ -0,0 +1,54 @@
+package errcode
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[int]*AppError)
+)
+
+// Register 注册业务错误码，错误码重复时直接panic
+// 应在包初始化阶段调用，以便尽早发现冲突
+func Register(code, status int, key, msg string) *AppError {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if exist, ok := registry[code]; ok {
+		panic(fmt.Sprintf("errcode: 错误码 %d 重复注册 (%s / %s)", code, exist.Message, msg))
+	}
+
+	e := &AppError{
+		Code:    code,
+		Status:  status,
+		Key:     key,
+		Message: msg,
+	}
+	registry[code] = e
+	return e
+}
+
+// Lookup 根据错误码查找已注册的错误
+func Lookup(code int) (*AppError, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	e, ok := registry[code]
+	return e, ok
+}
+
+// All 返回按错误码排序的全部已注册错误
+func All() []*AppError {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	list := make([]*AppError, 0, len(registry))
+	for _, e := range registry {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+	return list
+}
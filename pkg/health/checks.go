@@ -0,0 +1,64 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+)
+
+// LogWriter 检查日志目录可写：创建、写入并删除一个临时文件
+func LogWriter(dir string) func(ctx context.Context) error {
+	return func(context.Context) error {
+		f, err := os.CreateTemp(dir, ".health-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name())
+
+		if _, err := f.WriteString("ok"); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+}
+
+// DiskSpace 检查目录所在磁盘的剩余空间不少于minFreeMB
+func DiskSpace(dir string, minFreeMB uint64) func(ctx context.Context) error {
+	return func(context.Context) error {
+		free, err := freeBytes(dir)
+		if err != nil {
+			return err
+		}
+		if free < minFreeMB<<20 {
+			return fmt.Errorf("剩余空间 %dMB 低于 %dMB", free>>20, minFreeMB)
+		}
+		return nil
+	}
+}
+
+// Upstream 检查上游地址可以建立TCP连接，不发送HTTP请求，避免对上游产生副作用
+func Upstream(baseURL string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		u, err := url.Parse(baseURL)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("上游地址 %q 无效", baseURL)
+		}
+		port := u.Port()
+		if port == "" {
+			port = "80"
+			if u.Scheme == "https" {
+				port = "443"
+			}
+		}
+
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
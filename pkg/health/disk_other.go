@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd
+
+package health
+
+import "errors"
+
+// freeBytes 当前平台不支持读取磁盘剩余空间
+func freeBytes(string) (uint64, error) {
+	return 0, errors.New("当前平台不支持磁盘空间检查")
+}
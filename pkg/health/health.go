@@ -0,0 +1,274 @@
+package health
+
+import (
+	"FastGin/pkg/config"
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 检查默认值
+const (
+	DefaultTimeout  = 2 * time.Second
+	DefaultCacheTTL = 5 * time.Second
+)
+
+// 检查状态
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded" // 仅非关键检查失败
+	StatusFail     = "fail"
+)
+
+// Version 版本号，构建时通过 -ldflags "-X FastGin/pkg/health.Version=1.2.0" 写入
+var Version = "dev"
+
+// Check 一项依赖检查
+type Check struct {
+	Name     string
+	Critical bool          // 关键检查失败时就绪检查失败，非关键检查只在详情中展示
+	Timeout  time.Duration // 为0时使用配置的timeout
+	CacheTTL time.Duration // 结果缓存时间，为0时使用配置的cache_ttl
+	Fn       func(ctx context.Context) error
+}
+
+// Result 单项检查结果
+type Result struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Critical  bool      `json:"critical"`
+	Error     string    `json:"error,omitempty"`
+	Duration  string    `json:"duration"`
+	CheckedAt time.Time `json:"checked_at"`
+	Cached    bool      `json:"cached"`
+}
+
+// Report 一组检查的汇总结果
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Info 服务信息
+type Info struct {
+	Version   string    `json:"version"`
+	Commit    string    `json:"commit,omitempty"`
+	BuildTime string    `json:"build_time,omitempty"`
+	Modified  bool      `json:"modified,omitempty"` // 构建时工作区有未提交的修改
+	GoVersion string    `json:"go_version"`
+	Profile   string    `json:"profile"` // 运行模式，即 server.mode
+	StartedAt time.Time `json:"started_at"`
+	Uptime    string    `json:"uptime"`
+}
+
+// Details 详情接口返回的服务信息和全部检查结果
+type Details struct {
+	Info
+	Report
+	ShuttingDown bool `json:"shutting_down"`
+}
+
+// entry 已注册的检查及其缓存结果，mu 保证同一检查不会并发执行
+type entry struct {
+	check Check
+	mu    sync.Mutex
+	last  Result
+}
+
+var (
+	mu        sync.RWMutex
+	cfg       config.HealthConfig
+	profile   string
+	entries   []*entry
+	startedAt = time.Now()
+	stopping  atomic.Bool
+)
+
+// Init 设置默认超时和缓存时间，mode为运行模式（server.mode），在详情中作为profile返回
+func Init(c config.HealthConfig, mode string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+	if c.CacheTTL <= 0 {
+		c.CacheTTL = DefaultCacheTTL
+	}
+	cfg = c
+	profile = mode
+}
+
+// Register 注册检查，名称重复时返回错误
+func Register(c Check) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if c.Name == "" || c.Fn == nil {
+		return fmt.Errorf("health: 检查名称和检查函数不能为空")
+	}
+	for _, e := range entries {
+		if e.check.Name == c.Name {
+			return fmt.Errorf("health: 检查 %s 重复注册", c.Name)
+		}
+	}
+	entries = append(entries, &entry{check: c})
+	return nil
+}
+
+// SetShuttingDown 标记服务正在关闭，之后就绪检查始终失败，负载均衡据此摘除实例
+func SetShuttingDown() {
+	stopping.Store(true)
+}
+
+// ShuttingDown 服务是否正在关闭
+func ShuttingDown() bool {
+	return stopping.Load()
+}
+
+// Ready 执行关键检查，服务正在关闭或任一关键检查失败时返回失败
+func Ready(ctx context.Context) Report {
+	report := run(ctx, true)
+	if ShuttingDown() {
+		report.Status = StatusFail
+	}
+	return report
+}
+
+// Detail 执行全部检查并附带服务信息
+func Detail(ctx context.Context) Details {
+	return Details{
+		Info:         info(),
+		Report:       run(ctx, false),
+		ShuttingDown: ShuttingDown(),
+	}
+}
+
+// run 并发执行检查，criticalOnly为true时只执行关键检查
+func run(ctx context.Context, criticalOnly bool) Report {
+	mu.RLock()
+	var selected []*entry
+	for _, e := range entries {
+		if !criticalOnly || e.check.Critical {
+			selected = append(selected, e)
+		}
+	}
+	timeout, ttl := cfg.Timeout, cfg.CacheTTL
+	mu.RUnlock()
+
+	results := make([]Result, len(selected))
+	var wg sync.WaitGroup
+	for i, e := range selected {
+		wg.Add(1)
+		go func(i int, e *entry) {
+			defer wg.Done()
+			results[i] = e.run(ctx, timeout, ttl)
+		}(i, e)
+	}
+	wg.Wait()
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	report := Report{Status: StatusOK, Checks: results}
+	for _, r := range results {
+		if r.Status == StatusOK {
+			continue
+		}
+		if r.Critical {
+			report.Status = StatusFail
+			break
+		}
+		report.Status = StatusDegraded
+	}
+	return report
+}
+
+// run 执行检查，缓存未过期时直接返回上次结果
+func (e *entry) run(ctx context.Context, timeout, ttl time.Duration) Result {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.check.CacheTTL > 0 {
+		ttl = e.check.CacheTTL
+	}
+	if !e.last.CheckedAt.IsZero() && time.Since(e.last.CheckedAt) < ttl {
+		cached := e.last
+		cached.Cached = true
+		return cached
+	}
+
+	if e.check.Timeout > 0 {
+		timeout = e.check.Timeout
+	}
+	// 检查结果会被其他请求复用，不随当前请求取消
+	checkCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := e.call(checkCtx)
+	r := Result{
+		Name:      e.check.Name,
+		Status:    StatusOK,
+		Critical:  e.check.Critical,
+		Duration:  time.Since(start).Round(time.Microsecond).String(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		r.Status = StatusFail
+		r.Error = err.Error()
+	}
+	e.last = r
+	return r
+}
+
+// call 执行检查函数，超时后不再等待，检查函数未响应ctx时在后台结束
+func (e *entry) call(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				done <- fmt.Errorf("检查发生panic: %v", rec)
+			}
+		}()
+		done <- e.check.Fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("检查超时: %w", ctx.Err())
+	}
+}
+
+// info 版本、构建信息和运行时长
+func info() Info {
+	mu.RLock()
+	mode := profile
+	mu.RUnlock()
+
+	i := Info{
+		Version:   Version,
+		GoVersion: runtime.Version(),
+		Profile:   mode,
+		StartedAt: startedAt,
+		Uptime:    time.Since(startedAt).Round(time.Second).String(),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				i.Commit = s.Value
+			case "vcs.time":
+				i.BuildTime = s.Value
+			case "vcs.modified":
+				i.Modified = s.Value == "true"
+			}
+		}
+	}
+	return i
+}
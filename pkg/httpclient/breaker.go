@@ -0,0 +1,225 @@
+package httpclient
+
+import (
+	"FastGin/pkg/config"
+	"FastGin/pkg/logg"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// ErrCircuitOpen 熔断器打开时直接拒绝请求
+var ErrCircuitOpen = errors.New("httpclient: circuit breaker is open")
+
+// 默认熔断配置
+const (
+	DefaultFailureThreshold = 5
+	DefaultOpenTimeout      = 30 * time.Second
+	DefaultHalfOpenRequests = 1
+)
+
+// State 熔断器状态
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Breaker 单个上游主机的熔断器
+// closed: 正常放行，连续失败达到阈值后打开
+// open: 拒绝所有请求，超时后进入半开
+// half-open: 放行少量探测请求，成功则关闭，失败则重新打开
+// 每次状态切换递增代数，请求结果只计入放行它的那一代，避免慢请求影响切换后的状态
+type Breaker struct {
+	upstream string
+	host     string
+	cfg      config.BreakerConfig
+
+	mu         sync.Mutex
+	state      State
+	generation uint64
+	failures   int
+	openedAt   time.Time
+	inFlight   int
+	successes  int
+}
+
+// NewBreaker 创建熔断器
+func NewBreaker(upstream, host string, cfg config.BreakerConfig) *Breaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = DefaultFailureThreshold
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = DefaultOpenTimeout
+	}
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = DefaultHalfOpenRequests
+	}
+	return &Breaker{
+		upstream: upstream,
+		host:     host,
+		cfg:      cfg,
+	}
+}
+
+// State 返回当前状态
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.currentState(time.Now())
+}
+
+// currentState 获取状态，open超时后转为half-open，调用方需持有锁
+func (b *Breaker) currentState(now time.Time) State {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		b.setState(StateHalfOpen)
+	}
+	return b.state
+}
+
+// Allow 判断是否放行请求，返回放行时的代数，请求结束后传给Success或Failure
+func (b *Breaker) Allow() (uint64, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.currentState(time.Now()) {
+	case StateOpen:
+		return b.generation, false
+	case StateHalfOpen:
+		if b.inFlight >= b.cfg.HalfOpenRequests {
+			return b.generation, false
+		}
+		b.inFlight++
+	}
+	return b.generation, true
+}
+
+// Success 记录一次成功，放行后状态已切换的请求不计入
+func (b *Breaker) Success(generation uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		return
+	}
+	switch b.state {
+	case StateHalfOpen:
+		if b.inFlight > 0 {
+			b.inFlight--
+		}
+		b.successes++
+		if b.successes >= b.cfg.HalfOpenRequests {
+			b.setState(StateClosed)
+		}
+	default:
+		b.failures = 0
+	}
+}
+
+// Failure 记录一次失败，放行后状态已切换的请求不计入
+func (b *Breaker) Failure(generation uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		return
+	}
+	switch b.state {
+	case StateHalfOpen:
+		b.setState(StateOpen)
+	case StateClosed:
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.setState(StateOpen)
+		}
+	}
+}
+
+// setState 切换状态并记录日志，调用方需持有锁
+func (b *Breaker) setState(to State) {
+	from := b.state
+	if from == to {
+		return
+	}
+
+	b.state = to
+	b.generation++
+	b.failures = 0
+	b.successes = 0
+	b.inFlight = 0
+	if to == StateOpen {
+		b.openedAt = time.Now()
+	}
+
+	logg.Warn("熔断器状态变更", map[string]interface{}{
+		"upstream": b.upstream,
+		"host":     b.host,
+		"from":     from.String(),
+		"to":       to.String(),
+	})
+}
+
+// breakerGroup 按主机管理熔断器
+type breakerGroup struct {
+	upstream string
+	cfg      config.BreakerConfig
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+func newBreakerGroup(upstream string, cfg config.BreakerConfig) *breakerGroup {
+	return &breakerGroup{
+		upstream: upstream,
+		cfg:      cfg,
+		breakers: make(map[string]*Breaker),
+	}
+}
+
+// get 获取指定主机的熔断器
+func (g *breakerGroup) get(host string) *Breaker {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	b, ok := g.breakers[host]
+	if !ok {
+		b = NewBreaker(g.upstream, host, g.cfg)
+		g.breakers[host] = b
+	}
+	return b
+}
+
+// wrap 熔断中间件，传输错误和5xx响应计为失败
+func (g *breakerGroup) wrap(rt req.RoundTripper) req.RoundTripFunc {
+	return func(r *req.Request) (*req.Response, error) {
+		b := g.get(r.URL.Host)
+		generation, ok := b.Allow()
+		if !ok {
+			return &req.Response{Request: r, Err: ErrCircuitOpen}, ErrCircuitOpen
+		}
+
+		resp, err := rt.RoundTrip(r)
+		if err != nil || (resp != nil && resp.Response != nil && resp.StatusCode >= http.StatusInternalServerError) {
+			b.Failure(generation)
+		} else {
+			b.Success(generation)
+		}
+		return resp, err
+	}
+}
@@ -0,0 +1,179 @@
+package httpclient
+
+import (
+	"FastGin/pkg/config"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fail 放行一次请求并记录失败
+func fail(b *Breaker) {
+	generation, _ := b.Allow()
+	b.Failure(generation)
+}
+
+// allowed 判断是否放行，忽略代数
+func allowed(b *Breaker) bool {
+	_, ok := b.Allow()
+	return ok
+}
+
+func TestBreakerStates(t *testing.T) {
+	b := NewBreaker("test", "example.com", config.BreakerConfig{
+		FailureThreshold: 3,
+		OpenTimeout:      30 * time.Millisecond,
+		HalfOpenRequests: 1,
+	})
+
+	// 成功会重置连续失败计数
+	fail(b)
+	fail(b)
+	generation, _ := b.Allow()
+	b.Success(generation)
+	fail(b)
+	fail(b)
+	if b.State() != StateClosed {
+		t.Fatalf("state %v, want closed", b.State())
+	}
+
+	fail(b)
+	if b.State() != StateOpen || allowed(b) {
+		t.Fatalf("state %v, want open and rejecting", b.State())
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("state %v, want half-open", b.State())
+	}
+	probe, ok := b.Allow()
+	if !ok {
+		t.Fatal("half-open should allow a probe")
+	}
+	if allowed(b) {
+		t.Fatal("half-open should allow only half_open_requests probes")
+	}
+
+	b.Success(probe)
+	if b.State() != StateClosed || !allowed(b) {
+		t.Fatalf("state %v, want closed", b.State())
+	}
+}
+
+func TestBreakerHalfOpenFailureReopens(t *testing.T) {
+	b := NewBreaker("test", "example.com", config.BreakerConfig{
+		FailureThreshold: 1,
+		OpenTimeout:      20 * time.Millisecond,
+	})
+	fail(b)
+	time.Sleep(30 * time.Millisecond)
+	probe, ok := b.Allow()
+	if !ok {
+		t.Fatal("half-open should allow a probe")
+	}
+
+	b.Failure(probe)
+	if b.State() != StateOpen || allowed(b) {
+		t.Fatalf("state %v, want open", b.State())
+	}
+}
+
+func TestBreakerIgnoresStaleOutcomes(t *testing.T) {
+	b := NewBreaker("test", "example.com", config.BreakerConfig{
+		FailureThreshold: 1,
+		OpenTimeout:      20 * time.Millisecond,
+	})
+
+	// closed时放行的慢请求在熔断打开并进入半开后才返回
+	slow, _ := b.Allow()
+	fail(b)
+	time.Sleep(30 * time.Millisecond)
+	probe, ok := b.Allow()
+	if !ok {
+		t.Fatal("half-open should allow a probe")
+	}
+
+	// 慢请求的成功不能关闭熔断，也不能占用探测名额
+	b.Success(slow)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("state %v after stale success, want half-open", b.State())
+	}
+	if allowed(b) {
+		t.Fatal("stale success released a probe slot")
+	}
+
+	// 慢请求的失败也不能重新打开熔断
+	b.Failure(slow)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("state %v after stale failure, want half-open", b.State())
+	}
+
+	b.Success(probe)
+	if b.State() != StateClosed {
+		t.Fatalf("state %v, want closed", b.State())
+	}
+}
+
+func TestBreakerClient(t *testing.T) {
+	var hits atomic.Int32
+	var healthy atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, config.UpstreamConfig{
+		BaseURL: srv.URL,
+		Breaker: config.BreakerConfig{
+			Enabled:          true,
+			FailureThreshold: 2,
+			OpenTimeout:      50 * time.Millisecond,
+		},
+	})
+
+	// closed：5xx计为失败，达到阈值后打开
+	for i := 0; i < 2; i++ {
+		resp, err := c.R().Get("/")
+		if err != nil || resp.StatusCode != http.StatusInternalServerError {
+			t.Fatalf("resp %v err %v", resp, err)
+		}
+	}
+
+	// open：不再访问上游
+	if _, err := c.R().Get("/"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err %v, want ErrCircuitOpen", err)
+	}
+	if hits.Load() != 2 {
+		t.Fatalf("hits %d, want 2", hits.Load())
+	}
+
+	// half-open：探测成功后关闭
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		resp, err := c.R().Get("/")
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("request %d: resp %v err %v", i, resp, err)
+		}
+	}
+	if hits.Load() != 5 {
+		t.Fatalf("hits %d, want 5", hits.Load())
+	}
+}
+
+func TestBreakerPerHost(t *testing.T) {
+	g := newBreakerGroup("test", config.BreakerConfig{FailureThreshold: 1})
+	fail(g.get("a.example.com"))
+	if allowed(g.get("a.example.com")) {
+		t.Fatal("a.example.com should be open")
+	}
+	if !allowed(g.get("b.example.com")) {
+		t.Fatal("b.example.com should be unaffected")
+	}
+}
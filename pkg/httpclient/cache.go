@@ -0,0 +1,405 @@
+package httpclient
+
+import (
+	"FastGin/pkg/cache"
+	"FastGin/pkg/config"
+	"FastGin/pkg/logg"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"golang.org/x/sync/singleflight"
+)
+
+// 默认缓存配置
+const (
+	DefaultCacheTTL = time.Minute
+)
+
+// CacheHeader 响应头，标识缓存命中情况：HIT、MISS、REVALIDATED
+const CacheHeader = "X-Cache"
+
+// cacheKeyPrefix 缓存键前缀，完整格式为 httpcache:<上游>:<路径>|<方法>|<参数摘要>
+const cacheKeyPrefix = "httpcache:"
+
+// CacheStats 缓存统计
+type CacheStats struct {
+	Hits        int64 `json:"hits"`
+	Misses      int64 `json:"misses"`
+	Revalidated int64 `json:"revalidated"`
+	Shared      int64 `json:"shared"` // 合并到其他并发请求的次数
+	Stores      int64 `json:"stores"`
+	Errors      int64 `json:"errors"` // 缓存存储读写失败次数
+}
+
+// cacheEntry 缓存的响应
+type cacheEntry struct {
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header,omitempty"`
+	Body     []byte      `json:"body,omitempty"`
+	ExpireAt time.Time   `json:"expire_at"`
+
+	cacheable bool
+	ttl       time.Duration
+}
+
+// fresh 是否仍在有效期内
+func (e *cacheEntry) fresh() bool {
+	return time.Now().Before(e.ExpireAt)
+}
+
+// validators 是否可以通过ETag或Last-Modified重新验证
+func (e *cacheEntry) validators() bool {
+	return e.Header.Get("ETag") != "" || e.Header.Get("Last-Modified") != ""
+}
+
+// response 由缓存构建响应，每个调用方获得独立的副本
+func (e *cacheEntry) response(r *req.Request, status string) *req.Response {
+	header := e.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Set(CacheHeader, status)
+	resp := &req.Response{
+		Request: r,
+		Response: &http.Response{
+			Status:        strconv.Itoa(e.Status) + " " + http.StatusText(e.Status),
+			StatusCode:    e.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(e.Body)),
+			ContentLength: int64(len(e.Body)),
+		},
+	}
+	resp.SetBody(e.Body)
+	return resp
+}
+
+// responseCache 上游响应缓存中间件，位于最外层，缓存键不受签名、重试和代理影响
+type responseCache struct {
+	upstream   string
+	cfg        config.CacheConfig
+	store      cache.Store
+	signParams map[string]bool
+	group      singleflight.Group
+
+	hits, misses, revalidated, shared, stores, errors atomic.Int64
+}
+
+func newResponseCache(upstream string, cfg config.CacheConfig, store cache.Store, signParams []string) *responseCache {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultCacheTTL
+	}
+	return &responseCache{
+		upstream:   upstream,
+		cfg:        cfg,
+		store:      store,
+		signParams: toSet(signParams, strings.ToLower),
+	}
+}
+
+// rule 返回请求匹配的缓存规则，未匹配时返回false
+func (c *responseCache) rule(r *req.Request) (config.CacheRule, bool) {
+	if len(c.cfg.Rules) == 0 {
+		return config.CacheRule{Method: http.MethodGet}, r.Method == http.MethodGet
+	}
+	for _, rule := range c.cfg.Rules {
+		method := rule.Method
+		if method == "" {
+			method = http.MethodGet
+		}
+		if !strings.EqualFold(method, r.Method) {
+			continue
+		}
+		if prefix, ok := strings.CutSuffix(rule.Path, "*"); ok {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				return rule, true
+			}
+		} else if rule.Path == r.URL.Path {
+			return rule, true
+		}
+	}
+	return config.CacheRule{}, false
+}
+
+// key 由方法、路径、会话账号和选定参数构建缓存键
+// 不同账号的响应互不共享，签名生成的参数不参与缓存键
+func (c *responseCache) key(r *req.Request, rule config.CacheRule) string {
+	params := url.Values{}
+	for name, values := range r.URL.Query() {
+		params[name] = values
+	}
+	for name, values := range r.FormData {
+		params[name] = values
+	}
+
+	keyParams := rule.KeyParams
+	if len(keyParams) == 0 {
+		keyParams = c.cfg.KeyParams
+	}
+	selected := url.Values{}
+	if len(keyParams) > 0 {
+		for _, name := range keyParams {
+			if values, ok := params[name]; ok {
+				selected[name] = values
+			}
+		}
+	} else {
+		for name, values := range params {
+			if !c.signParams[strings.ToLower(name)] {
+				selected[name] = values
+			}
+		}
+	}
+
+	h := sha1.New()
+	if s, ok := r.Context().Value(sessionKey{}).(*Session); ok {
+		io.WriteString(h, s.Account)
+	}
+	io.WriteString(h, "\n"+selected.Encode())
+	if len(r.FormData) == 0 && len(keyParams) == 0 && len(r.Body) > 0 {
+		io.WriteString(h, "\n")
+		h.Write(r.Body)
+	}
+
+	return c.prefix(r.URL.Path) + r.Method + "|" + hex.EncodeToString(h.Sum(nil))
+}
+
+// prefix 返回上游或指定路径的缓存键前缀
+func (c *responseCache) prefix(path string) string {
+	if path == "" {
+		return cacheKeyPrefix + c.upstream + ":"
+	}
+	return cacheKeyPrefix + c.upstream + ":" + path + "|"
+}
+
+func (c *responseCache) wrap(rt req.RoundTripper) req.RoundTripFunc {
+	return func(r *req.Request) (*req.Response, error) {
+		rule, ok := c.rule(r)
+		if !ok || noStore(r.Headers) {
+			return rt.RoundTrip(r)
+		}
+
+		key := c.key(r, rule)
+		cached := c.load(r.Context(), key)
+		if cached != nil && cached.fresh() && !noCache(r.Headers) {
+			c.hits.Add(1)
+			return cached.response(r, "HIT"), nil
+		}
+
+		var leaderResp *req.Response
+		v, err, _ := c.group.Do(key, func() (interface{}, error) {
+			resp, err := c.fetch(r, rt, rule, key, cached)
+			leaderResp = resp
+			if err != nil {
+				return nil, err
+			}
+			return c.entry(resp, rule), nil
+		})
+		// 只有执行请求的调用方持有原始响应，其余调用方由共享结果构建响应
+		if leaderResp == nil {
+			c.shared.Add(1)
+			if err != nil {
+				return &req.Response{Request: r, Err: err}, err
+			}
+			return v.(*cacheEntry).response(r, "MISS"), nil
+		}
+		return leaderResp, err
+	}
+}
+
+// fetch 向上游发送请求，有可验证的过期缓存时携带条件请求头，304时以缓存构建响应
+func (c *responseCache) fetch(r *req.Request, rt req.RoundTripper, rule config.CacheRule, key string, cached *cacheEntry) (*req.Response, error) {
+	revalidate := cached != nil && cached.validators()
+	if revalidate {
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			r.SetHeader("If-None-Match", etag)
+		}
+		if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" {
+			r.SetHeader("If-Modified-Since", lastModified)
+		}
+	}
+
+	resp, err := rt.RoundTrip(r)
+	if err != nil || resp == nil || resp.Response == nil {
+		c.misses.Add(1)
+		return resp, err
+	}
+
+	if revalidate && resp.StatusCode == http.StatusNotModified {
+		c.revalidated.Add(1)
+		// 以304响应中的缓存头更新有效期
+		for _, name := range []string{"Cache-Control", "Expires", "ETag", "Last-Modified", "Date"} {
+			if v := resp.Header.Get(name); v != "" {
+				cached.Header.Set(name, v)
+			}
+		}
+		refreshed := &cacheEntry{Status: cached.Status, Header: cached.Header, Body: cached.Body}
+		c.save(r.Context(), key, c.decide(refreshed, rule))
+		return refreshed.response(r, "REVALIDATED"), nil
+	}
+
+	c.misses.Add(1)
+	entry := c.entry(resp, rule)
+	if entry.cacheable {
+		c.save(r.Context(), key, entry)
+	}
+	resp.Header.Set(CacheHeader, "MISS")
+	return resp, nil
+}
+
+// entry 由上游响应构建缓存条目并判断是否可缓存
+func (c *responseCache) entry(resp *req.Response, rule config.CacheRule) *cacheEntry {
+	header := resp.Header.Clone()
+	header.Del(CacheHeader)
+	header.Del("Set-Cookie")
+	e := c.decide(&cacheEntry{
+		Status: resp.StatusCode,
+		Header: header,
+		Body:   resp.Bytes(),
+	}, rule)
+	// 设置Cookie的响应通常与会话状态相关，只共享给并发请求，不写入缓存
+	if resp.Header.Get("Set-Cookie") != "" {
+		e.cacheable = false
+	}
+	return e
+}
+
+// decide 根据规则和Cache-Control确定有效期，仅缓存200响应
+// 规则配置了ttl时以规则为准，否则依次使用max-age和默认ttl；no-store和带Set-Cookie的响应不缓存
+func (c *responseCache) decide(e *cacheEntry, rule config.CacheRule) *cacheEntry {
+	directives := parseCacheControl(e.Header.Get("Cache-Control"))
+	if e.Status != http.StatusOK || directives["no-store"] != "" {
+		return e
+	}
+
+	ttl := rule.TTL
+	if ttl <= 0 {
+		ttl = c.cfg.TTL
+		if maxAge, ok := directives["max-age"]; ok {
+			if seconds, err := strconv.Atoi(maxAge); err == nil {
+				ttl = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	if directives["no-cache"] != "" {
+		ttl = 0
+	}
+	// 无法重新验证且已过期的响应没有保存价值
+	if ttl <= 0 && !e.validators() {
+		return e
+	}
+
+	e.cacheable = true
+	e.ttl = ttl
+	e.ExpireAt = time.Now().Add(ttl)
+	return e
+}
+
+// load 读取缓存条目，读取失败时视为未命中
+func (c *responseCache) load(ctx context.Context, key string) *cacheEntry {
+	data, ok, err := c.store.Get(ctx, key)
+	if err != nil {
+		c.errors.Add(1)
+		logg.Warn("读取上游响应缓存失败", map[string]interface{}{
+			"upstream": c.upstream,
+			"error":    err.Error(),
+		})
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		c.errors.Add(1)
+		return nil
+	}
+	return &e
+}
+
+// save 写入缓存条目，有验证头的条目额外保留stale_ttl用于重新验证
+func (c *responseCache) save(ctx context.Context, key string, e *cacheEntry) {
+	if !e.cacheable {
+		return
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		c.errors.Add(1)
+		return
+	}
+
+	ttl := e.ttl
+	if e.validators() {
+		ttl += c.cfg.StaleTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+	if err := c.store.Set(ctx, key, data, ttl); err != nil {
+		c.errors.Add(1)
+		logg.Warn("写入上游响应缓存失败", map[string]interface{}{
+			"upstream": c.upstream,
+			"error":    err.Error(),
+		})
+		return
+	}
+	c.stores.Add(1)
+}
+
+// purge 清除上游的缓存，path非空时只清除该路径
+func (c *responseCache) purge(ctx context.Context, path string) (int, error) {
+	return c.store.DeletePrefix(ctx, c.prefix(path))
+}
+
+func (c *responseCache) stats() CacheStats {
+	return CacheStats{
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Revalidated: c.revalidated.Load(),
+		Shared:      c.shared.Load(),
+		Stores:      c.stores.Load(),
+		Errors:      c.errors.Load(),
+	}
+}
+
+// noStore 请求是否要求不使用缓存
+func noStore(h http.Header) bool {
+	return parseCacheControl(h.Get("Cache-Control"))["no-store"] != ""
+}
+
+// noCache 请求是否要求跳过新鲜缓存，向上游重新获取或验证
+func noCache(h http.Header) bool {
+	return parseCacheControl(h.Get("Cache-Control"))["no-cache"] != ""
+}
+
+// parseCacheControl 解析Cache-Control指令，无取值的指令以自身作为值
+func parseCacheControl(value string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, val, found := strings.Cut(part, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !found {
+			val = name
+		}
+		directives[name] = strings.Trim(strings.TrimSpace(val), `"`)
+	}
+	return directives
+}
@@ -0,0 +1,334 @@
+package httpclient
+
+import (
+	"FastGin/pkg/config"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/imroc/req/v3"
+)
+
+// 录制模式
+const (
+	CassetteRecord = "record"
+	CassetteReplay = "replay"
+)
+
+// masked 脱敏后的占位值
+const masked = "******"
+
+// 默认脱敏的请求头和参数
+var (
+	defaultMaskHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+	defaultMaskParams  = []string{"password", "secret", "token", "access_token", "refresh_token"}
+)
+
+// Interaction 一次录制的请求与响应
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest 录制的请求
+type RecordedRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// RecordedResponse 录制的响应
+type RecordedResponse struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// masker 请求头、查询参数和请求体脱敏
+type masker struct {
+	maskHeaders map[string]bool
+	maskParams  map[string]bool
+}
+
+// newMasker 在默认脱敏列表基础上追加指定的请求头和参数
+func newMasker(headers, params []string) masker {
+	return masker{
+		maskHeaders: toSet(append(append([]string{}, defaultMaskHeaders...), headers...), http.CanonicalHeaderKey),
+		maskParams:  toSet(append(append([]string{}, defaultMaskParams...), params...), strings.ToLower),
+	}
+}
+
+// Cassette 录制文件，记录某个上游的全部交互
+type Cassette struct {
+	masker
+	path         string
+	mode         string
+	ignoreParams map[string]bool
+
+	mu sync.Mutex
+	// Note 录制说明，如数据来源和生成方式，仅供阅读，录制时不会生成，需手工填写
+	Note         string        `json:"note,omitempty"`
+	Interactions []Interaction `json:"interactions"`
+	used         []bool
+}
+
+// NewCassette 根据配置创建录制文件，回放模式下会加载已有记录
+// signParams 为签名中间件生成的参数，回放匹配时忽略其取值
+func NewCassette(cfg config.CassetteConfig, signParams ...string) (*Cassette, error) {
+	if cfg.Mode != CassetteRecord && cfg.Mode != CassetteReplay {
+		return nil, fmt.Errorf("httpclient: 未知的录制模式 %q", cfg.Mode)
+	}
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("httpclient: 录制模式需要配置 cassette.path")
+	}
+
+	k := &Cassette{
+		masker:       newMasker(cfg.MaskHeaders, cfg.MaskParams),
+		path:         cfg.Path,
+		mode:         cfg.Mode,
+		ignoreParams: toSet(append(signParams, cfg.IgnoreParams...), strings.ToLower),
+	}
+
+	if cfg.Mode == CassetteReplay {
+		data, err := os.ReadFile(cfg.Path)
+		if err != nil {
+			return nil, fmt.Errorf("httpclient: 读取录制文件失败: %w", err)
+		}
+		if err := json.Unmarshal(data, k); err != nil {
+			return nil, fmt.Errorf("httpclient: 解析录制文件失败: %w", err)
+		}
+		k.used = make([]bool, len(k.Interactions))
+	}
+	return k, nil
+}
+
+// wrap 传输层中间件，record模式转发并记录，replay模式直接返回匹配的记录
+func (k *Cassette) wrap(rt http.RoundTripper) req.HttpRoundTripFunc {
+	return func(r *http.Request) (*http.Response, error) {
+		body, err := readRequestBody(r)
+		if err != nil {
+			return nil, err
+		}
+
+		if k.mode == CassetteReplay {
+			return k.replay(r, body)
+		}
+
+		resp, err := rt.RoundTrip(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := k.record(r, body, resp); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		return resp, nil
+	}
+}
+
+// record 记录一次交互并立即写入文件
+func (k *Cassette) record(r *http.Request, body []byte, resp *http.Response) error {
+	raw, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(raw))
+
+	respHeaders := k.maskHeader(resp.Header)
+	stored := raw
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		if zr, err := gzip.NewReader(bytes.NewReader(raw)); err == nil {
+			if plain, err := io.ReadAll(zr); err == nil {
+				stored = plain
+				respHeaders.Del("Content-Encoding")
+				respHeaders.Del("Content-Length")
+			}
+		}
+	}
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method:  r.Method,
+			URL:     k.maskURL(r.URL),
+			Headers: k.maskHeader(r.Header),
+			Body:    k.maskBody(r.Header.Get("Content-Type"), body, k.maskParams),
+		},
+		Response: RecordedResponse{
+			Status:  resp.StatusCode,
+			Headers: respHeaders,
+			Body:    string(stored),
+		},
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.Interactions = append(k.Interactions, interaction)
+	return k.save()
+}
+
+// replay 按方法、URL和规范化后的请求体查找记录，优先使用尚未回放过的记录
+func (k *Cassette) replay(r *http.Request, body []byte) (*http.Response, error) {
+	contentType := r.Header.Get("Content-Type")
+	key := k.matchKey(r.Method, k.maskURL(r.URL), contentType, k.maskBody(contentType, body, k.maskParams))
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	found := -1
+	for i, it := range k.Interactions {
+		itKey := k.matchKey(it.Request.Method, it.Request.URL, it.Request.Headers.Get("Content-Type"), it.Request.Body)
+		if itKey != key {
+			continue
+		}
+		found = i
+		if !k.used[i] {
+			break
+		}
+	}
+	if found < 0 {
+		return nil, fmt.Errorf("httpclient: 录制文件 %s 中没有匹配的请求 %s %s", k.path, r.Method, r.URL.String())
+	}
+	k.used[found] = true
+
+	rec := k.Interactions[found].Response
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.Status, http.StatusText(rec.Status)),
+		StatusCode:    rec.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rec.Headers.Clone(),
+		Body:          io.NopCloser(strings.NewReader(rec.Body)),
+		ContentLength: int64(len(rec.Body)),
+		Request:       r,
+	}, nil
+}
+
+// matchKey 生成匹配用的规范化键，忽略参数的取值统一替换为占位值
+func (k *Cassette) matchKey(method, rawURL, contentType, body string) string {
+	u, err := url.Parse(rawURL)
+	if err == nil {
+		u.RawQuery = maskValues(u.Query(), k.ignoreParams).Encode()
+		rawURL = u.String()
+	}
+	return method + " " + rawURL + "\n" + k.maskBody(contentType, []byte(body), k.ignoreParams)
+}
+
+// maskURL 对查询参数脱敏并排序
+func (k masker) maskURL(u *url.URL) string {
+	cp := *u
+	cp.RawQuery = maskValues(u.Query(), k.maskParams).Encode()
+	return cp.String()
+}
+
+// maskHeader 对敏感请求头脱敏
+func (k masker) maskHeader(h http.Header) http.Header {
+	cp := h.Clone()
+	for name := range cp {
+		if k.maskHeaders[http.CanonicalHeaderKey(name)] {
+			cp[name] = []string{masked}
+		}
+	}
+	return cp
+}
+
+// maskBody 规范化请求体：表单按键排序，JSON按键排序后压缩，并对指定参数脱敏
+func (k masker) maskBody(contentType string, body []byte, keys map[string]bool) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	switch {
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		values, err := url.ParseQuery(string(body))
+		if err == nil {
+			return maskValues(values, keys).Encode()
+		}
+	case strings.Contains(contentType, "json"):
+		var obj interface{}
+		if err := json.Unmarshal(body, &obj); err == nil {
+			normalized, _ := json.Marshal(maskJSON(obj, keys))
+			return string(normalized)
+		}
+	}
+	return string(body)
+}
+
+// save 将全部记录写入文件，调用方需持有锁
+func (k *Cassette) save() error {
+	if err := os.MkdirAll(filepath.Dir(k.path), 0755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(k); err != nil {
+		return err
+	}
+	return os.WriteFile(k.path, buf.Bytes(), 0644)
+}
+
+// readRequestBody 读取请求体并恢复，便于后续继续发送
+func readRequestBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// maskValues 对指定参数脱敏
+func maskValues(values url.Values, keys map[string]bool) url.Values {
+	for name := range values {
+		if keys[strings.ToLower(name)] {
+			values[name] = []string{masked}
+		}
+	}
+	return values
+}
+
+// maskJSON 递归对JSON对象中的指定字段脱敏
+func maskJSON(v interface{}, keys map[string]bool) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for name, item := range val {
+			if keys[strings.ToLower(name)] {
+				val[name] = masked
+				continue
+			}
+			val[name] = maskJSON(item, keys)
+		}
+	case []interface{}:
+		for i, item := range val {
+			val[i] = maskJSON(item, keys)
+		}
+	}
+	return v
+}
+
+func toSet(list []string, normalize func(string) string) map[string]bool {
+	set := make(map[string]bool, len(list))
+	for _, item := range list {
+		if item != "" {
+			set[normalize(item)] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,238 @@
+package httpclient
+
+import (
+	"FastGin/pkg/cache"
+	"FastGin/pkg/config"
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"github.com/redis/go-redis/v9"
+)
+
+// 默认超时配置
+const (
+	DefaultTimeout             = 30 * time.Second
+	DefaultDialTimeout         = 10 * time.Second
+	DefaultTLSHandshakeTimeout = 10 * time.Second
+)
+
+// Client 上游服务客户端，内嵌req.Client，同一上游的所有请求共享连接池
+type Client struct {
+	*req.Client
+	Name   string
+	Config config.UpstreamConfig
+
+	sessions *SessionManager
+	debug    *debugMiddleware
+	cache    *responseCache
+}
+
+// Option 客户端创建选项
+type Option func(*options)
+
+type options struct {
+	proxyPool *ProxyPool
+	redis     *redis.Client
+}
+
+// WithProxyPool 指定出口代理池，仅对配置了use_proxy_pool的上游生效
+func WithProxyPool(pool *ProxyPool) Option {
+	return func(o *options) {
+		o.proxyPool = pool
+	}
+}
+
+// WithRedis 指定Redis客户端，供backend为redis的响应缓存使用
+func WithRedis(client *redis.Client) Option {
+	return func(o *options) {
+		o.redis = client
+	}
+}
+
+// New 根据上游配置创建客户端，签名配置无效时返回错误
+func New(name string, cfg config.UpstreamConfig, opts ...Option) (*Client, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = DefaultDialTimeout
+	}
+	if cfg.TLSHandshakeTimeout <= 0 {
+		cfg.TLSHandshakeTimeout = DefaultTLSHandshakeTimeout
+	}
+
+	c := req.C().
+		SetTimeout(cfg.Timeout).
+		SetTLSHandshakeTimeout(cfg.TLSHandshakeTimeout).
+		SetDial((&net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: 30 * time.Second}).DialContext)
+
+	if cfg.BaseURL != "" {
+		c.SetBaseURL(cfg.BaseURL)
+	}
+	if cfg.ForceHTTP2 {
+		c.EnableForceHTTP2()
+	}
+	if cfg.UseProxyPool && o.proxyPool != nil {
+		c.SetProxy(o.proxyPool.proxyFunc)
+	} else if cfg.Proxy != "" {
+		c.SetProxyURL(cfg.Proxy)
+	}
+	// uTLS握手不携带客户端证书，两者不能同时使用
+	if cfg.Profile != "" && cfg.TLS.CertFile != "" {
+		return nil, fmt.Errorf("httpclient: 上游 %s 的客户端指纹不支持双向TLS证书", name)
+	}
+	// 指纹需在自定义请求头之前应用，使headers可以覆盖指纹中的默认请求头
+	if err := applyProfile(c, cfg.Profile); err != nil {
+		return nil, err
+	}
+	if len(cfg.Headers) > 0 {
+		c.SetCommonHeaders(cfg.Headers)
+	}
+	if !cfg.CookieJar {
+		c.SetCookieJar(nil)
+	}
+
+	// 调试日志位于最内层，记录签名、会话和代理处理后实际发出的每次尝试
+	debug := newDebugMiddleware(name, cfg.Debug)
+	c.WrapRoundTripFunc(debug.wrap)
+
+	applyTLS(c, cfg.TLS)
+	// 预算紧贴调试日志之外，每次尝试的context只覆盖实际发送，尝试结束即释放
+	applyBudget(c, cfg.Budget)
+	applyRetry(c, name, cfg.Retry)
+	if cfg.UseProxyPool && o.proxyPool != nil {
+		// 位于熔断之内，熔断拒绝的请求不计入代理统计，重试时重新选择代理
+		c.WrapRoundTripFunc(o.proxyPool.wrap)
+	}
+	if cfg.Breaker.Enabled {
+		c.WrapRoundTripFunc(newBreakerGroup(name, cfg.Breaker).wrap)
+	}
+
+	signer, err := newSignMiddleware(cfg.Sign)
+	if err != nil {
+		return nil, err
+	}
+	if signer != nil {
+		c.WrapRoundTripFunc(signer.wrap)
+	}
+
+	if cfg.Cassette.Mode != "" {
+		if cfg.Sign.Header != "" {
+			cfg.Cassette.MaskHeaders = append(cfg.Cassette.MaskHeaders, cfg.Sign.Header)
+		}
+		cassette, err := NewCassette(cfg.Cassette, signParams(signer)...)
+		if err != nil {
+			return nil, err
+		}
+		c.GetTransport().WrapRoundTripFunc(cassette.wrap)
+	}
+
+	client := &Client{
+		Client: c,
+		Name:   name,
+		Config: cfg,
+		debug:  debug,
+	}
+	client.sessions, err = newSessionManager(client, cfg.Session)
+	if err != nil {
+		return nil, err
+	}
+	c.WrapRoundTripFunc(client.sessions.wrap)
+
+	if cfg.Cache.Enabled {
+		store, err := newCacheStore(name, cfg.Cache, o.redis)
+		if err != nil {
+			return nil, err
+		}
+		// 位于最外层，缓存命中时不经过会话、签名、熔断和代理
+		client.cache = newResponseCache(name, cfg.Cache, store, signParams(signer))
+		c.WrapRoundTripFunc(client.cache.wrap)
+	}
+	// 指标位于缓存之外，缓存命中也计入调用数
+	c.WrapRoundTripFunc((&metricsMiddleware{upstream: name}).wrap)
+	// 追踪位于最外层，缓存命中也会创建span，内层的调试日志可读取当前span
+	c.WrapRoundTripFunc((&tracingMiddleware{upstream: name}).wrap)
+
+	return client, nil
+}
+
+// CacheStats 返回响应缓存统计，未启用缓存时ok为false
+func (c *Client) CacheStats() (stats CacheStats, ok bool) {
+	if c.cache == nil {
+		return CacheStats{}, false
+	}
+	return c.cache.stats(), true
+}
+
+// PurgeCache 清除响应缓存，path非空时只清除该路径，返回清除的条目数
+func (c *Client) PurgeCache(ctx context.Context, path string) (int, error) {
+	if c.cache == nil {
+		return 0, nil
+	}
+	return c.cache.purge(ctx, path)
+}
+
+// Sessions 返回按账号隔离的会话管理器
+func (c *Client) Sessions() *SessionManager {
+	return c.sessions
+}
+
+// SetDebug 运行时切换调试日志和完整转储
+func (c *Client) SetDebug(state DebugState) {
+	c.debug.set(state)
+}
+
+// Debug 返回当前调试日志开关状态
+func (c *Client) Debug() DebugState {
+	return c.debug.state()
+}
+
+// Session 获取指定账号的会话客户端
+func (c *Client) Session(account string) (*Session, error) {
+	return c.sessions.For(account)
+}
+
+// newCacheStore 根据配置创建响应缓存存储
+func newCacheStore(name string, cfg config.CacheConfig, client *redis.Client) (cache.Store, error) {
+	switch cfg.Backend {
+	case "", "memory":
+		return cache.NewLRU(cfg.Size), nil
+	case "redis":
+		if client == nil {
+			return nil, fmt.Errorf("httpclient: 上游 %s 的响应缓存需要配置 redis", name)
+		}
+		return cache.NewRedis(client), nil
+	default:
+		return nil, fmt.Errorf("httpclient: 未知的缓存后端 %q", cfg.Backend)
+	}
+}
+
+// applyTLS 应用TLS配置
+func applyTLS(c *req.Client, cfg config.TLSConfig) {
+	if cfg.InsecureSkipVerify {
+		c.EnableInsecureSkipVerify()
+	}
+	if cfg.ServerName != "" {
+		c.GetTLSClientConfig().ServerName = cfg.ServerName
+	}
+	if cfg.CAFile != "" {
+		c.SetRootCertsFromFile(cfg.CAFile)
+	}
+	if cfg.CertFile != "" && cfg.KeyFile != "" {
+		c.SetCertFromFile(cfg.CertFile, cfg.KeyFile)
+	}
+}
+
+// Close 关闭空闲连接和调试转储文件
+func (c *Client) Close() {
+	c.GetTransport().CloseIdleConnections()
+	c.debug.close()
+}
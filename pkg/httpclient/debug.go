@@ -0,0 +1,241 @@
+package httpclient
+
+import (
+	"FastGin/pkg/config"
+	"FastGin/pkg/logg"
+	"FastGin/pkg/tracing"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/imroc/req/v3"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// DefaultBodyLimit 摘要日志中请求体和响应体的默认截断长度
+const DefaultBodyLimit = 512
+
+// requestIDKey 请求上下文中保存入站请求ID的键
+type requestIDKey struct{}
+
+// WithRequestID 将入站请求ID写入上下文，上游调用日志据此关联到入站请求
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID 从上下文中读取入站请求ID
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// DebugState 调试日志开关状态
+type DebugState struct {
+	Enabled bool `json:"enabled"`
+	Dump    bool `json:"dump"`
+}
+
+// debugMiddleware 上游请求调试日志，位于最内层，记录的是实际发出的每次尝试
+type debugMiddleware struct {
+	upstream string
+	cfg      config.DebugConfig
+	masker   masker
+
+	enabled atomic.Bool
+	dump    atomic.Bool
+
+	dumpOnce sync.Once
+	dumpMu   sync.Mutex
+	dumpFile *lumberjack.Logger
+}
+
+func newDebugMiddleware(upstream string, cfg config.DebugConfig) *debugMiddleware {
+	if cfg.BodyLimit <= 0 {
+		cfg.BodyLimit = DefaultBodyLimit
+	}
+	if cfg.DumpDir == "" {
+		cfg.DumpDir = "logs"
+	}
+
+	d := &debugMiddleware{
+		upstream: upstream,
+		cfg:      cfg,
+		masker:   newMasker(cfg.MaskHeaders, cfg.MaskParams),
+	}
+	d.set(DebugState{Enabled: cfg.Enabled, Dump: cfg.Dump})
+	return d
+}
+
+// set 切换开关，转储依赖调试日志开启
+func (d *debugMiddleware) set(state DebugState) {
+	d.enabled.Store(state.Enabled || state.Dump)
+	d.dump.Store(state.Dump)
+}
+
+func (d *debugMiddleware) state() DebugState {
+	return DebugState{Enabled: d.enabled.Load(), Dump: d.dump.Load()}
+}
+
+func (d *debugMiddleware) wrap(rt req.RoundTripper) req.RoundTripFunc {
+	return func(r *req.Request) (*req.Response, error) {
+		if !d.enabled.Load() {
+			return rt.RoundTrip(r)
+		}
+
+		r.EnableTrace()
+		start := time.Now()
+		resp, err := rt.RoundTrip(r)
+		d.log(r, resp, err, time.Since(start))
+		return resp, err
+	}
+}
+
+// log 记录请求摘要，调试模式下同时写入完整转储
+func (d *debugMiddleware) log(r *req.Request, resp *req.Response, err error, elapsed time.Duration) {
+	raw := r.RawRequest
+	if raw == nil {
+		// 请求未能构建，例如读取请求体失败
+		logg.Warn("上游请求未发出", tracing.LogFields(r.Context(), map[string]interface{}{
+			"request_id": RequestID(r.Context()),
+			"upstream":   d.upstream,
+			"method":     r.Method,
+			"url":        r.RawURL,
+			"error":      errString(err),
+		}))
+		return
+	}
+
+	contentType := raw.Header.Get("Content-Type")
+	reqBody := d.masker.maskBody(contentType, r.Body, d.masker.maskParams)
+	fields := tracing.LogFields(r.Context(), map[string]interface{}{
+		"request_id": RequestID(r.Context()),
+		"upstream":   d.upstream,
+		"attempt":    r.RetryAttempt + 1,
+		"method":     raw.Method,
+		"url":        d.masker.maskURL(raw.URL),
+		"headers":    flattenHeader(d.masker.maskHeader(raw.Header)),
+		"body":       truncate(reqBody, d.cfg.BodyLimit),
+		"latency":    elapsed.String(),
+	})
+
+	var respBody string
+	if resp != nil && resp.Response != nil {
+		respBody = d.masker.maskBody(resp.Header.Get("Content-Type"), resp.Bytes(), d.masker.maskParams)
+		fields["status"] = resp.StatusCode
+		fields["response"] = truncate(respBody, d.cfg.BodyLimit)
+
+		trace := resp.TraceInfo()
+		fields["dns"] = trace.DNSLookupTime.String()
+		fields["connect"] = trace.TCPConnectTime.String()
+		fields["tls"] = trace.TLSHandshakeTime.String()
+		fields["ttfb"] = trace.FirstResponseTime.String()
+		fields["conn_reused"] = trace.IsConnReused
+	}
+
+	if err != nil || (resp != nil && resp.Response != nil && resp.StatusCode >= http.StatusInternalServerError) {
+		fields["error"] = errString(err)
+		logg.Warn("上游请求", fields)
+	} else {
+		logg.Info("上游请求", fields)
+	}
+
+	if d.dump.Load() {
+		d.writeDump(r, resp, err, elapsed, reqBody, respBody)
+	}
+}
+
+// writeDump 将完整请求和响应写入 <dump_dir>/upstream-<name>.dump，按大小滚动
+func (d *debugMiddleware) writeDump(r *req.Request, resp *req.Response, err error, elapsed time.Duration, reqBody, respBody string) {
+	d.dumpOnce.Do(func() {
+		d.dumpFile = &lumberjack.Logger{
+			Filename:   filepath.Join(d.cfg.DumpDir, "upstream-"+d.upstream+".dump"),
+			MaxSize:    100,
+			MaxBackups: 5,
+			MaxAge:     7,
+			Compress:   true,
+		}
+	})
+
+	raw := r.RawRequest
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "=== %s request_id=%s attempt=%d latency=%s\n",
+		time.Now().Format("2006-01-02 15:04:05.000"), RequestID(r.Context()), r.RetryAttempt+1, elapsed)
+	fmt.Fprintf(&buf, "%s %s\n", raw.Method, d.masker.maskURL(raw.URL))
+	writeHeader(&buf, d.masker.maskHeader(raw.Header))
+	fmt.Fprintf(&buf, "\n%s\n", reqBody)
+
+	switch {
+	case resp != nil && resp.Response != nil:
+		fmt.Fprintf(&buf, "--- %s %s\n", resp.Proto, resp.Status)
+		writeHeader(&buf, d.masker.maskHeader(resp.Header))
+		fmt.Fprintf(&buf, "\n%s\n", respBody)
+	case err != nil:
+		fmt.Fprintf(&buf, "--- error: %s\n", err)
+	}
+	buf.WriteString("\n")
+
+	d.dumpMu.Lock()
+	defer d.dumpMu.Unlock()
+	if _, err := d.dumpFile.Write(buf.Bytes()); err != nil {
+		logg.Error("写入上游请求转储失败", map[string]interface{}{
+			"upstream": d.upstream,
+			"error":    err.Error(),
+		})
+	}
+}
+
+// close 关闭转储文件
+func (d *debugMiddleware) close() {
+	d.dumpMu.Lock()
+	defer d.dumpMu.Unlock()
+	if d.dumpFile != nil {
+		d.dumpFile.Close()
+	}
+}
+
+// flattenHeader 将请求头转为便于日志输出的键值
+func flattenHeader(h http.Header) map[string]string {
+	flat := make(map[string]string, len(h))
+	for name, values := range h {
+		flat[name] = strings.Join(values, ", ")
+	}
+	return flat
+}
+
+// writeHeader 按名称排序输出请求头
+func writeHeader(buf *bytes.Buffer, h http.Header) {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(buf, "%s: %s\n", name, strings.Join(h[name], ", "))
+	}
+}
+
+// truncate 截断过长的内容，不截断多字节字符
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+	return s[:limit] + fmt.Sprintf("...(%d bytes)", len(s))
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
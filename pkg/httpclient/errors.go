@@ -0,0 +1,116 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/imroc/req/v3"
+)
+
+// maxErrorBody 错误信息中保留的响应体长度
+const maxErrorBody = 512
+
+// Kind 上游调用失败的类别
+type Kind int
+
+const (
+	KindTransport Kind = iota + 1 // 网络或连接错误，含熔断拒绝
+	KindTimeout                   // 超时
+	KindClient                    // 上游返回4xx
+	KindServer                    // 上游返回5xx
+	KindDecode                    // 响应无法解析
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindTransport:
+		return "transport"
+	case KindTimeout:
+		return "timeout"
+	case KindClient:
+		return "client"
+	case KindServer:
+		return "server"
+	case KindDecode:
+		return "decode"
+	}
+	return "unknown"
+}
+
+// UpstreamError 上游调用错误
+type UpstreamError struct {
+	Upstream   string
+	Kind       Kind
+	StatusCode int
+	Body       string
+	Err        error
+}
+
+// Error 实现error接口
+func (e *UpstreamError) Error() string {
+	msg := fmt.Sprintf("upstream %s %s error", e.Upstream, e.Kind)
+	if e.StatusCode != 0 {
+		msg += fmt.Sprintf(" (status %d)", e.StatusCode)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap 返回原始错误
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+// Check 检查请求结果，将传输错误、超时、4xx和5xx响应分别归类为UpstreamError
+func (c *Client) Check(resp *req.Response, err error) error {
+	if err == nil && resp != nil {
+		err = resp.Err
+	}
+	if err != nil {
+		kind := KindTransport
+		if isTimeout(err) {
+			kind = KindTimeout
+		}
+		return &UpstreamError{Upstream: c.Name, Kind: kind, Err: err}
+	}
+
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return &UpstreamError{Upstream: c.Name, Kind: KindServer, StatusCode: resp.StatusCode, Body: snippet(resp)}
+	case resp.StatusCode >= http.StatusBadRequest:
+		return &UpstreamError{Upstream: c.Name, Kind: KindClient, StatusCode: resp.StatusCode, Body: snippet(resp)}
+	}
+	return nil
+}
+
+// Decode 将JSON响应体解析到v
+func (c *Client) Decode(resp *req.Response, v interface{}) error {
+	if err := json.Unmarshal(resp.Bytes(), v); err != nil {
+		return &UpstreamError{Upstream: c.Name, Kind: KindDecode, StatusCode: resp.StatusCode, Body: snippet(resp), Err: err}
+	}
+	return nil
+}
+
+// isTimeout 判断是否为超时错误
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// snippet 截取响应体用于错误信息
+func snippet(resp *req.Response) string {
+	body := resp.String()
+	if len(body) > maxErrorBody {
+		body = body[:maxErrorBody]
+	}
+	return body
+}
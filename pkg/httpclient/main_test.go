@@ -0,0 +1,10 @@
+package httpclient
+
+import (
+	"FastGin/pkg/testutil"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	testutil.Main(m)
+}
@@ -0,0 +1,164 @@
+package httpclient
+
+import (
+	"FastGin/pkg/cache"
+	"FastGin/pkg/config"
+	"FastGin/pkg/logg"
+	"fmt"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Manager 管理各上游服务的共享客户端
+type Manager struct {
+	mu      sync.RWMutex
+	configs map[string]config.UpstreamConfig
+	clients map[string]*Client
+
+	proxyPool *ProxyPool
+	redis     *redis.Client
+}
+
+// NewManager 根据配置文件中的upstreams和proxy_pool创建客户端管理器
+// 所有已配置的客户端在启动时创建，配置有误时直接返回错误
+func NewManager(cfg *config.Config) (*Manager, error) {
+	pool, err := NewProxyPool(cfg.ProxyPool)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{
+		configs:   make(map[string]config.UpstreamConfig, len(cfg.Upstreams)),
+		clients:   make(map[string]*Client, len(cfg.Upstreams)),
+		proxyPool: pool,
+	}
+	if cfg.Redis.Addr != "" {
+		m.redis, err = cache.NewRedisClient(cfg.Redis)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for name, cfg := range cfg.Upstreams {
+		c, err := New(name, cfg, m.options()...)
+		if err != nil {
+			return nil, fmt.Errorf("创建上游 %s 客户端失败: %w", name, err)
+		}
+		m.configs[name] = cfg
+		m.clients[name] = c
+	}
+	if pool != nil {
+		pool.Start()
+	}
+	return m, nil
+}
+
+// options 创建客户端时使用的公共选项
+func (m *Manager) options() []Option {
+	return []Option{WithProxyPool(m.proxyPool), WithRedis(m.redis)}
+}
+
+// Redis 返回共享的Redis客户端，未配置时返回nil
+func (m *Manager) Redis() *redis.Client {
+	return m.redis
+}
+
+// CacheStats 返回已启用响应缓存的各上游缓存统计
+func (m *Manager) CacheStats() map[string]CacheStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := make(map[string]CacheStats)
+	for name, c := range m.clients {
+		if s, ok := c.CacheStats(); ok {
+			stats[name] = s
+		}
+	}
+	return stats
+}
+
+// ProxyPool 返回出口代理池，未配置时返回nil
+func (m *Manager) ProxyPool() *ProxyPool {
+	return m.proxyPool
+}
+
+// Client 获取指定上游的共享客户端，未配置的上游使用默认配置
+func (m *Manager) Client(name string) *Client {
+	m.mu.RLock()
+	c, ok := m.clients[name]
+	m.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if c, ok := m.clients[name]; ok {
+		return c
+	}
+
+	logg.Warn("上游服务未配置，使用默认客户端配置", map[string]interface{}{
+		"upstream": name,
+	})
+	// 默认配置不含签名，不会创建失败
+	c, _ = New(name, config.UpstreamConfig{})
+	m.clients[name] = c
+	return c
+}
+
+// Lookup 获取已创建的客户端，不存在时不会创建默认客户端
+func (m *Manager) Lookup(name string) (*Client, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, ok := m.clients[name]
+	return c, ok
+}
+
+// Reload 使用新配置重建指定上游的客户端，旧客户端的空闲连接会被关闭
+func (m *Manager) Reload(name string, cfg config.UpstreamConfig) (*Client, error) {
+	c, err := New(name, cfg, m.options()...)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if old, ok := m.clients[name]; ok {
+		old.Close()
+	}
+	m.configs[name] = cfg
+	m.clients[name] = c
+	return c, nil
+}
+
+// Names 返回已配置的上游名称
+func (m *Manager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.configs))
+	for name := range m.configs {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Close 停止代理健康检查，关闭所有客户端的空闲连接和Redis连接，应在服务退出时调用
+func (m *Manager) Close() {
+	if m.proxyPool != nil {
+		m.proxyPool.Stop()
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, c := range m.clients {
+		c.Close()
+	}
+	if m.redis != nil {
+		m.redis.Close()
+	}
+}
@@ -0,0 +1,54 @@
+package httpclient
+
+import (
+	"FastGin/pkg/metrics"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+var (
+	upstreamRequests = metrics.NewCounterVec("upstream_requests_total",
+		"按上游和结果统计的出站请求尝试数，每次重试单独计数", "upstream", "outcome")
+	upstreamDuration = metrics.NewHistogramVec("upstream_request_duration_seconds",
+		"按上游统计的单次出站请求尝试耗时，包含排队等待，不含重试间隔", nil, "upstream")
+)
+
+// metricsMiddleware 出站调用指标，位于缓存之外、追踪之内，耗时包含在当前尝试的span中。
+// req的重试循环在中间件链之外，因此每次尝试（含重试）单独统计
+type metricsMiddleware struct {
+	upstream string
+}
+
+func (m *metricsMiddleware) wrap(rt req.RoundTripper) req.RoundTripFunc {
+	return func(r *req.Request) (*req.Response, error) {
+		start := time.Now()
+		resp, err := rt.RoundTrip(r)
+		upstreamRequests.WithLabelValues(m.upstream, outcome(resp, err)).Inc()
+		upstreamDuration.WithLabelValues(m.upstream).Observe(time.Since(start).Seconds())
+		return resp, err
+	}
+}
+
+// outcome 出站调用结果：success、cached、client_error、server_error、timeout、circuit_open、no_proxy、error
+func outcome(resp *req.Response, err error) string {
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, ErrNoProxy):
+		return "no_proxy"
+	case err != nil && isTimeout(err):
+		return "timeout"
+	case err != nil || resp == nil || resp.Response == nil:
+		return "error"
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return "server_error"
+	case resp.StatusCode >= http.StatusBadRequest:
+		return "client_error"
+	case resp.Header.Get(CacheHeader) == "HIT":
+		return "cached"
+	}
+	return "success"
+}
@@ -0,0 +1,186 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/imroc/req/v3"
+	"github.com/imroc/req/v3/http2"
+	utls "github.com/refraction-networking/utls"
+)
+
+// Profile 客户端指纹配置，统一设置TLS ClientHello、HTTP/2参数、请求头顺序和User-Agent
+type Profile func(c *req.Client)
+
+var (
+	profilesMu sync.RWMutex
+	profiles   = map[string]Profile{
+		"okhttp4": OkHttp4,
+		"chrome":  Chrome,
+		"safari":  Safari,
+		"firefox": Firefox,
+	}
+)
+
+// RegisterProfile 注册客户端指纹，供配置中的profile引用
+func RegisterProfile(name string, profile Profile) {
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	profiles[name] = profile
+}
+
+// applyProfile 按名称应用客户端指纹，名称为空时保持Go默认握手
+func applyProfile(c *req.Client, name string) error {
+	if name == "" {
+		return nil
+	}
+
+	profilesMu.RLock()
+	profile, ok := profiles[name]
+	profilesMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("httpclient: 未知的客户端指纹 %q", name)
+	}
+	profile(c)
+	return nil
+}
+
+// okhttp4 在HTTP/2下的连接参数与请求头顺序
+var (
+	okHttpSettings = []http2.Setting{
+		{ID: http2.SettingInitialWindowSize, Val: 16777216},
+	}
+
+	okHttpPseudoHeaderOrder = []string{
+		":method",
+		":path",
+		":authority",
+		":scheme",
+	}
+
+	okHttpHeaderOrder = []string{
+		"authorization",
+		"content-type",
+		"content-length",
+		"host",
+		"connection",
+		"accept-encoding",
+		"cookie",
+		"user-agent",
+	}
+)
+
+// OkHttp4 模拟Android上的OkHttp 4.x客户端
+// utls内置的OkHttp ClientHello不含ALPN扩展，这里补充h2和http/1.1，与OkHttp实际握手一致
+func OkHttp4(c *req.Client) {
+	c.SetTLSHandshake(utlsHandshake(c, utls.HelloAndroid_11_OkHttp, "h2", "http/1.1")).
+		SetHTTP2SettingsFrame(okHttpSettings...).
+		SetHTTP2ConnectionFlow(16711681).
+		SetCommonPseudoHeaderOder(okHttpPseudoHeaderOrder...).
+		SetCommonHeaderOrder(okHttpHeaderOrder...).
+		SetCommonHeaders(map[string]string{
+			"user-agent":      "okhttp/4.9.3",
+			"accept-encoding": "gzip",
+		})
+}
+
+// Chrome 模拟桌面版Chrome浏览器
+func Chrome(c *req.Client) {
+	c.ImpersonateChrome()
+}
+
+// Safari 模拟macOS上的Safari浏览器
+func Safari(c *req.Client) {
+	c.ImpersonateSafari()
+}
+
+// Firefox 模拟桌面版Firefox浏览器
+func Firefox(c *req.Client) {
+	c.ImpersonateFirefox()
+}
+
+// utlsHandshake 使用指定ClientHello执行TLS握手，alpn非空时替换ClientHello中的ALPN扩展
+func utlsHandshake(c *req.Client, id utls.ClientHelloID, alpn ...string) func(ctx context.Context, addr string, plainConn net.Conn) (net.Conn, *tls.ConnectionState, error) {
+	return func(ctx context.Context, addr string, plainConn net.Conn) (net.Conn, *tls.ConnectionState, error) {
+		hostname := addr
+		if i := strings.LastIndex(addr, ":"); i != -1 {
+			hostname = addr[:i]
+		}
+		tlsConfig := c.GetTLSClientConfig()
+		if tlsConfig.ServerName != "" {
+			hostname = tlsConfig.ServerName
+		}
+
+		// 扩展对象带有握手状态，每个连接都需要重新生成
+		spec, err := utls.UTLSIdToSpec(id)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(alpn) > 0 {
+			setALPN(&spec, alpn)
+		}
+
+		uconn := utls.UClient(plainConn, &utls.Config{
+			ServerName:         hostname,
+			RootCAs:            tlsConfig.RootCAs,
+			InsecureSkipVerify: tlsConfig.InsecureSkipVerify,
+			KeyLogWriter:       tlsConfig.KeyLogWriter,
+		}, utls.HelloCustom)
+		if err := uconn.ApplyPreset(&spec); err != nil {
+			return nil, nil, err
+		}
+		if err := uconn.HandshakeContext(ctx); err != nil {
+			return nil, nil, err
+		}
+
+		conn := &utlsConn{uconn}
+		state := conn.ConnectionState()
+		return conn, &state, nil
+	}
+}
+
+// setALPN 替换或追加ALPN扩展，追加时放在padding扩展之前
+func setALPN(spec *utls.ClientHelloSpec, protocols []string) {
+	for _, ext := range spec.Extensions {
+		if e, ok := ext.(*utls.ALPNExtension); ok {
+			e.AlpnProtocols = protocols
+			return
+		}
+	}
+
+	alpn := &utls.ALPNExtension{AlpnProtocols: protocols}
+	for i, ext := range spec.Extensions {
+		if _, ok := ext.(*utls.UtlsPaddingExtension); ok {
+			spec.Extensions = append(spec.Extensions[:i], append([]utls.TLSExtension{alpn}, spec.Extensions[i:]...)...)
+			return
+		}
+	}
+	spec.Extensions = append(spec.Extensions, alpn)
+}
+
+// utlsConn 将utls连接状态转换为标准库类型，供HTTP/2协商使用
+type utlsConn struct {
+	*utls.UConn
+}
+
+// ConnectionState 返回标准库格式的连接状态
+func (conn *utlsConn) ConnectionState() tls.ConnectionState {
+	cs := conn.Conn.ConnectionState()
+	return tls.ConnectionState{
+		Version:                    cs.Version,
+		HandshakeComplete:          cs.HandshakeComplete,
+		DidResume:                  cs.DidResume,
+		CipherSuite:                cs.CipherSuite,
+		NegotiatedProtocol:         cs.NegotiatedProtocol,
+		NegotiatedProtocolIsMutual: cs.NegotiatedProtocolIsMutual,
+		ServerName:                 cs.ServerName,
+		PeerCertificates:           cs.PeerCertificates,
+		VerifiedChains:             cs.VerifiedChains,
+		OCSPResponse:               cs.OCSPResponse,
+		TLSUnique:                  cs.TLSUnique,
+	}
+}
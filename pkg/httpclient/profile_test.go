@@ -0,0 +1,209 @@
+package httpclient
+
+import (
+	"FastGin/pkg/config"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// clientHello 从原始握手中解析的ClientHello字段
+type clientHello struct {
+	cipherSuites []uint16
+	extensions   []uint16
+	alpn         []string
+}
+
+// captureClientHello 启动只读取ClientHello的本地TLS服务端，使用指定指纹发起请求并返回捕获的ClientHello
+func captureClientHello(t *testing.T, profile string) *clientHello {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	captured := make(chan *clientHello, 1)
+	failed := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			failed <- err
+			return
+		}
+		// 读取后直接断开，客户端握手失败
+		defer conn.Close()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		hello, err := readClientHello(conn)
+		if err != nil {
+			failed <- err
+			return
+		}
+		captured <- hello
+	}()
+
+	c := newTestClient(t, config.UpstreamConfig{Profile: profile, Timeout: 5 * time.Second})
+	// 使用域名访问，ClientHello中才会带上server_name扩展
+	port := ln.Addr().(*net.TCPAddr).Port
+	c.R().Get("https://localhost:" + strconv.Itoa(port) + "/")
+
+	select {
+	case hello := <-captured:
+		return hello
+	case err := <-failed:
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no ClientHello received")
+	}
+	return nil
+}
+
+// readClientHello 读取握手记录并解析ClientHello，握手消息可能跨多个记录
+func readClientHello(r io.Reader) (*clientHello, error) {
+	var msg []byte
+	for len(msg) < 4 || len(msg) < 4+handshakeLen(msg) {
+		header := make([]byte, 5)
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, err
+		}
+		if header[0] != 0x16 {
+			return nil, errors.New("not a handshake record")
+		}
+		payload := make([]byte, binary.BigEndian.Uint16(header[3:]))
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return nil, err
+		}
+		msg = append(msg, payload...)
+	}
+	if msg[0] != 0x01 {
+		return nil, errors.New("not a ClientHello")
+	}
+
+	s := &reader{data: msg[4 : 4+handshakeLen(msg)]}
+	s.skip(2 + 32)      // client_version, random
+	s.skip(int(s.u8())) // session_id
+	ciphers := s.next(int(s.u16()))
+	s.skip(int(s.u8())) // compression_methods
+	exts := s.next(int(s.u16()))
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	hello := &clientHello{}
+	cs := &reader{data: ciphers}
+	for len(cs.data) > 0 && cs.err == nil {
+		hello.cipherSuites = append(hello.cipherSuites, cs.u16())
+	}
+	es := &reader{data: exts}
+	for len(es.data) > 0 && es.err == nil {
+		typ := es.u16()
+		body := &reader{data: es.next(int(es.u16()))}
+		hello.extensions = append(hello.extensions, typ)
+		if typ == 16 { // application_layer_protocol_negotiation
+			list := &reader{data: body.next(int(body.u16()))}
+			for len(list.data) > 0 && list.err == nil {
+				hello.alpn = append(hello.alpn, string(list.next(int(list.u8()))))
+			}
+		}
+	}
+	return hello, es.err
+}
+
+// handshakeLen 握手消息头中的3字节长度
+func handshakeLen(msg []byte) int {
+	return int(msg[1])<<16 | int(msg[2])<<8 | int(msg[3])
+}
+
+// reader 按TLS编码顺序读取字段，越界时记录错误
+type reader struct {
+	data []byte
+	err  error
+}
+
+func (r *reader) next(n int) []byte {
+	if r.err != nil || n > len(r.data) {
+		r.err = errors.New("malformed ClientHello")
+		r.data = nil
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *reader) skip(n int) { r.next(n) }
+
+func (r *reader) u8() uint8 {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *reader) u16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func TestOkHttp4ClientHello(t *testing.T) {
+	hello := captureClientHello(t, "okhttp4")
+
+	wantCiphers := []uint16{
+		0xc02b, 0xc02c, 0xcca9, 0xc02f, 0xc030, 0xcca8,
+		0xc013, 0xc014, 0x009c, 0x009d, 0x002f, 0x0035,
+	}
+	// server_name、extended_master_secret、renegotiation_info、supported_groups、ec_point_formats、
+	// status_request、signature_algorithms，ALPN补在最后
+	wantExtensions := []uint16{0, 23, 65281, 10, 11, 5, 13, 16}
+	if !slices.Equal(hello.cipherSuites, wantCiphers) {
+		t.Errorf("cipher suites %#04x, want %#04x", hello.cipherSuites, wantCiphers)
+	}
+	if !slices.Equal(hello.extensions, wantExtensions) {
+		t.Errorf("extensions %v, want %v", hello.extensions, wantExtensions)
+	}
+	if !slices.Equal(hello.alpn, []string{"h2", "http/1.1"}) {
+		t.Errorf("alpn %q", hello.alpn)
+	}
+}
+
+func TestChromeClientHello(t *testing.T) {
+	hello := captureClientHello(t, "chrome")
+
+	wantCiphers := []uint16{
+		0x1301, 0x1302, 0x1303, 0xc02b, 0xc02f, 0xc02c, 0xc030,
+		0xcca9, 0xcca8, 0xc013, 0xc014, 0x009c, 0x009d, 0x002f, 0x0035,
+	}
+	if len(hello.cipherSuites) == 0 || !isGREASE(hello.cipherSuites[0]) {
+		t.Fatalf("cipher suites %#04x, want leading GREASE", hello.cipherSuites)
+	}
+	if !slices.Equal(hello.cipherSuites[1:], wantCiphers) {
+		t.Errorf("cipher suites %#04x, want GREASE + %#04x", hello.cipherSuites, wantCiphers)
+	}
+
+	// Chrome每次握手随机打乱扩展顺序，首尾固定为GREASE，中间只比较集合
+	exts := hello.extensions
+	if len(exts) < 2 || !isGREASE(exts[0]) || !isGREASE(exts[len(exts)-1]) {
+		t.Fatalf("extensions %v, want GREASE at both ends", exts)
+	}
+	got := slices.Clone(exts[1 : len(exts)-1])
+	slices.Sort(got)
+	wantExtensions := []uint16{0, 5, 10, 11, 13, 16, 18, 23, 27, 35, 43, 45, 51, 17513, 65037, 65281}
+	if !slices.Equal(got, wantExtensions) {
+		t.Errorf("extensions %v, want %v", got, wantExtensions)
+	}
+	if !slices.Equal(hello.alpn, []string{"h2", "http/1.1"}) {
+		t.Errorf("alpn %q", hello.alpn)
+	}
+}
+
+// isGREASE 是否为RFC 8701保留的GREASE值
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
@@ -0,0 +1,433 @@
+package httpclient
+
+import (
+	"FastGin/pkg/config"
+	"FastGin/pkg/logg"
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// 代理选择策略
+const (
+	StrategyRoundRobin  = "round_robin"
+	StrategyLeastErrors = "least_errors"
+	StrategySticky      = "sticky"
+)
+
+// 默认代理池配置
+const (
+	DefaultProbeInterval = 30 * time.Second
+	DefaultProbeTimeout  = 5 * time.Second
+	DefaultMaxFailures   = 3
+	DefaultQuarantine    = time.Minute
+	DefaultMaxSticky     = 10000
+)
+
+// ErrNoProxy 代理池中没有可用代理
+var ErrNoProxy = errors.New("httpclient: no healthy proxy available")
+
+// proxyKey 请求上下文中保存所选代理的键
+type proxyKey struct{}
+
+// Proxy 代理池中的单个代理
+type Proxy struct {
+	url *url.URL
+
+	mu                  sync.Mutex
+	successes           int64
+	failures            int64
+	consecutiveFailures int
+	totalLatency        time.Duration
+	lastLatency         time.Duration
+	lastError           string
+	lastCheck           time.Time
+	quarantinedUntil    time.Time
+}
+
+// ProxyStats 代理统计信息
+type ProxyStats struct {
+	URL              string    `json:"url"`
+	Healthy          bool      `json:"healthy"`
+	Successes        int64     `json:"successes"`
+	Failures         int64     `json:"failures"`
+	SuccessRate      float64   `json:"success_rate"`
+	AvgLatencyMs     int64     `json:"avg_latency_ms"`
+	LastLatencyMs    int64     `json:"last_latency_ms"`
+	LastError        string    `json:"last_error,omitempty"`
+	LastCheck        time.Time `json:"last_check,omitempty"`
+	QuarantinedUntil time.Time `json:"quarantined_until,omitempty"`
+}
+
+// Redacted 返回隐藏密码后的代理地址
+func (p *Proxy) Redacted() string {
+	return p.url.Redacted()
+}
+
+// healthy 判断代理当前是否可用，调用方需持有锁
+func (p *Proxy) healthy(now time.Time) bool {
+	return now.After(p.quarantinedUntil)
+}
+
+// errorRate 失败率，调用方需持有锁
+func (p *Proxy) errorRate() float64 {
+	total := p.successes + p.failures
+	if total == 0 {
+		return 0
+	}
+	return float64(p.failures) / float64(total)
+}
+
+// Stats 返回统计快照
+func (p *Proxy) Stats() ProxyStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := ProxyStats{
+		URL:              p.Redacted(),
+		Healthy:          p.healthy(time.Now()),
+		Successes:        p.successes,
+		Failures:         p.failures,
+		SuccessRate:      1 - p.errorRate(),
+		LastLatencyMs:    p.lastLatency.Milliseconds(),
+		LastError:        p.lastError,
+		LastCheck:        p.lastCheck,
+		QuarantinedUntil: p.quarantinedUntil,
+	}
+	if p.successes > 0 {
+		stats.AvgLatencyMs = (p.totalLatency / time.Duration(p.successes)).Milliseconds()
+	}
+	return stats
+}
+
+// ProxyPool 出口代理池，支持轮询、最少错误和按账号粘滞三种选择策略，
+// 连续失败的代理会被隔离，隔离期满或健康检查通过后恢复
+type ProxyPool struct {
+	cfg     config.ProxyPoolConfig
+	proxies []*Proxy
+
+	mu          sync.Mutex
+	next        int
+	sticky      map[string]*list.Element // 元素值为*stickyProxy
+	stickyOrder *list.List               // 队首为最近使用，超过max_sticky时淘汰队尾
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// stickyProxy 粘滞策略下账号固定使用的代理
+type stickyProxy struct {
+	account string
+	proxy   *Proxy
+}
+
+// NewProxyPool 根据配置创建代理池，未配置代理时返回nil
+func NewProxyPool(cfg config.ProxyPoolConfig) (*ProxyPool, error) {
+	if len(cfg.Proxies) == 0 {
+		return nil, nil
+	}
+
+	switch cfg.Strategy {
+	case "":
+		cfg.Strategy = StrategyRoundRobin
+	case StrategyRoundRobin, StrategyLeastErrors, StrategySticky:
+	default:
+		return nil, fmt.Errorf("httpclient: 未知的代理选择策略 %q", cfg.Strategy)
+	}
+	if cfg.ProbeInterval <= 0 {
+		cfg.ProbeInterval = DefaultProbeInterval
+	}
+	if cfg.ProbeTimeout <= 0 {
+		cfg.ProbeTimeout = DefaultProbeTimeout
+	}
+	if cfg.MaxFailures <= 0 {
+		cfg.MaxFailures = DefaultMaxFailures
+	}
+	if cfg.Quarantine <= 0 {
+		cfg.Quarantine = DefaultQuarantine
+	}
+	if cfg.MaxSticky <= 0 {
+		cfg.MaxSticky = DefaultMaxSticky
+	}
+
+	pool := &ProxyPool{
+		cfg:         cfg,
+		sticky:      make(map[string]*list.Element),
+		stickyOrder: list.New(),
+	}
+	for _, pc := range cfg.Proxies {
+		u, err := url.Parse(pc.URL)
+		if err != nil {
+			return nil, fmt.Errorf("httpclient: 代理地址 %q 无效: %w", pc.URL, err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return nil, fmt.Errorf("httpclient: 不支持的代理协议 %q", u.Scheme)
+		}
+		if pc.Username != "" {
+			u.User = url.UserPassword(pc.Username, pc.Password)
+		}
+		pool.proxies = append(pool.proxies, &Proxy{url: u})
+	}
+	return pool, nil
+}
+
+// Select 按策略选择可用代理，account用于粘滞策略
+func (pool *ProxyPool) Select(account string) (*Proxy, error) {
+	now := time.Now()
+	healthy := make([]*Proxy, 0, len(pool.proxies))
+	for _, p := range pool.proxies {
+		p.mu.Lock()
+		ok := p.healthy(now)
+		p.mu.Unlock()
+		if ok {
+			healthy = append(healthy, p)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil, ErrNoProxy
+	}
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	switch {
+	case pool.cfg.Strategy == StrategySticky && account != "":
+		return pool.stickyProxy(account, healthy), nil
+	case pool.cfg.Strategy == StrategyLeastErrors:
+		return leastErrors(healthy), nil
+	default:
+		return pool.roundRobin(healthy), nil
+	}
+}
+
+// stickyProxy 返回账号固定使用的代理，原代理不可用时重新选择，调用方需持有锁
+// 只保留最近使用的max_sticky个账号，被淘汰的账号下次使用时重新分配代理
+func (pool *ProxyPool) stickyProxy(account string, healthy []*Proxy) *Proxy {
+	if el, ok := pool.sticky[account]; ok {
+		pool.stickyOrder.MoveToFront(el)
+		entry := el.Value.(*stickyProxy)
+		if !contains(healthy, entry.proxy) {
+			entry.proxy = pool.roundRobin(healthy)
+		}
+		return entry.proxy
+	}
+
+	p := pool.roundRobin(healthy)
+	pool.sticky[account] = pool.stickyOrder.PushFront(&stickyProxy{account: account, proxy: p})
+	for pool.stickyOrder.Len() > pool.cfg.MaxSticky {
+		evicted := pool.stickyOrder.Remove(pool.stickyOrder.Back()).(*stickyProxy)
+		delete(pool.sticky, evicted.account)
+	}
+	return p
+}
+
+// roundRobin 轮询选择，调用方需持有锁
+func (pool *ProxyPool) roundRobin(healthy []*Proxy) *Proxy {
+	p := healthy[pool.next%len(healthy)]
+	pool.next++
+	return p
+}
+
+// leastErrors 选择失败率最低的代理，失败率相同时选择延迟较低的
+func leastErrors(healthy []*Proxy) *Proxy {
+	type candidate struct {
+		proxy   *Proxy
+		rate    float64
+		latency time.Duration
+	}
+	candidates := make([]candidate, len(healthy))
+	for i, p := range healthy {
+		p.mu.Lock()
+		candidates[i] = candidate{proxy: p, rate: p.errorRate(), latency: p.lastLatency}
+		p.mu.Unlock()
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].rate != candidates[j].rate {
+			return candidates[i].rate < candidates[j].rate
+		}
+		return candidates[i].latency < candidates[j].latency
+	})
+	return candidates[0].proxy
+}
+
+func contains(list []*Proxy, p *Proxy) bool {
+	for _, item := range list {
+		if item == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Report 记录一次使用结果，连续失败达到阈值后隔离代理
+func (pool *ProxyPool) Report(p *Proxy, latency time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err == nil {
+		p.successes++
+		p.consecutiveFailures = 0
+		p.totalLatency += latency
+		p.lastLatency = latency
+		p.lastError = ""
+		return
+	}
+
+	p.failures++
+	p.consecutiveFailures++
+	p.lastError = err.Error()
+	if p.consecutiveFailures >= pool.cfg.MaxFailures && p.healthy(time.Now()) {
+		p.quarantinedUntil = time.Now().Add(pool.cfg.Quarantine)
+		logg.Warn("代理连续失败，已隔离", map[string]interface{}{
+			"proxy":      p.Redacted(),
+			"failures":   p.consecutiveFailures,
+			"quarantine": pool.cfg.Quarantine.String(),
+			"error":      p.lastError,
+		})
+	}
+}
+
+// Stats 返回全部代理的统计信息
+func (pool *ProxyPool) Stats() []ProxyStats {
+	stats := make([]ProxyStats, 0, len(pool.proxies))
+	for _, p := range pool.proxies {
+		stats = append(stats, p.Stats())
+	}
+	return stats
+}
+
+// proxyFunc 供http.Transport使用，返回请求上下文中已选择的代理
+func (pool *ProxyPool) proxyFunc(r *http.Request) (*url.URL, error) {
+	if p, ok := r.Context().Value(proxyKey{}).(*Proxy); ok {
+		return p.url, nil
+	}
+	return nil, nil
+}
+
+// wrap 代理池客户端中间件：发送前选择代理，完成后记录结果
+// 传输错误和407响应计为代理失败，粘滞策略按会话账号选择代理
+func (pool *ProxyPool) wrap(rt req.RoundTripper) req.RoundTripFunc {
+	return func(r *req.Request) (*req.Response, error) {
+		account := ""
+		if s, ok := r.Context().Value(sessionKey{}).(*Session); ok {
+			account = s.Account
+		}
+
+		p, err := pool.Select(account)
+		if err != nil {
+			return &req.Response{Request: r, Err: err}, err
+		}
+		r.SetContext(context.WithValue(r.Context(), proxyKey{}, p))
+
+		start := time.Now()
+		resp, err := rt.RoundTrip(r)
+		reportErr := err
+		if reportErr == nil && resp != nil && resp.Response != nil && resp.StatusCode == http.StatusProxyAuthRequired {
+			reportErr = fmt.Errorf("proxy authentication required")
+		}
+		pool.Report(p, time.Since(start), reportErr)
+		return resp, err
+	}
+}
+
+// Start 启动后台健康检查，未配置probe_url时不启动
+func (pool *ProxyPool) Start() {
+	if pool.cfg.ProbeURL == "" || pool.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pool.cancel = cancel
+	pool.done = make(chan struct{})
+
+	go func() {
+		defer close(pool.done)
+		ticker := time.NewTicker(pool.cfg.ProbeInterval)
+		defer ticker.Stop()
+
+		pool.probeAll(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				pool.probeAll(ctx)
+			}
+		}
+	}()
+}
+
+// Stop 停止健康检查
+func (pool *ProxyPool) Stop() {
+	if pool.cancel == nil {
+		return
+	}
+	pool.cancel()
+	<-pool.done
+	pool.cancel = nil
+}
+
+// probeAll 并发检查全部代理
+func (pool *ProxyPool) probeAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, p := range pool.proxies {
+		wg.Add(1)
+		go func(p *Proxy) {
+			defer wg.Done()
+			pool.probe(ctx, p)
+		}(p)
+	}
+	wg.Wait()
+}
+
+// probe 通过代理访问探测地址，成功则解除隔离，失败则计入连续失败
+func (pool *ProxyPool) probe(ctx context.Context, p *Proxy) {
+	ctx, cancel := context.WithTimeout(ctx, pool.cfg.ProbeTimeout)
+	defer cancel()
+
+	transport := &http.Transport{Proxy: http.ProxyURL(p.url), DisableKeepAlives: true}
+	defer transport.CloseIdleConnections()
+
+	start := time.Now()
+	err := func() error {
+		r, err := http.NewRequestWithContext(ctx, http.MethodGet, pool.cfg.ProbeURL, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := transport.RoundTrip(r)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("probe status %d", resp.StatusCode)
+		}
+		return nil
+	}()
+
+	p.mu.Lock()
+	p.lastCheck = time.Now()
+	recovered := err == nil && !p.healthy(time.Now())
+	if recovered {
+		p.quarantinedUntil = time.Time{}
+		p.consecutiveFailures = 0
+	}
+	p.mu.Unlock()
+
+	if recovered {
+		logg.Info("代理健康检查通过，已恢复", map[string]interface{}{
+			"proxy": p.Redacted(),
+		})
+	}
+	pool.Report(p, time.Since(start), err)
+}
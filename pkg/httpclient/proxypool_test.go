@@ -0,0 +1,38 @@
+package httpclient
+
+import (
+	"FastGin/pkg/config"
+	"testing"
+)
+
+func TestStickyEviction(t *testing.T) {
+	pool, err := NewProxyPool(config.ProxyPoolConfig{
+		Strategy:  StrategySticky,
+		MaxSticky: 2,
+		Proxies:   []config.ProxyConfig{{URL: "http://10.0.0.1:8080"}, {URL: "http://10.0.0.2:8080"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alice, _ := pool.Select("alice")
+	bob, _ := pool.Select("bob")
+	if alice == bob {
+		t.Fatal("accounts should be spread across proxies")
+	}
+	// 再次使用alice后bob成为最久未使用的账号，新账号加入时淘汰bob
+	if p, _ := pool.Select("alice"); p != alice {
+		t.Fatal("alice is not sticky")
+	}
+	pool.Select("carol")
+
+	if len(pool.sticky) != 2 || pool.stickyOrder.Len() != 2 {
+		t.Fatalf("sticky entries %d, want 2", len(pool.sticky))
+	}
+	if _, ok := pool.sticky["bob"]; ok {
+		t.Fatal("least recently used account was not evicted")
+	}
+	if p, _ := pool.Select("alice"); p != alice {
+		t.Fatal("alice lost its proxy after eviction of another account")
+	}
+}
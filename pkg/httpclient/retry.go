@@ -0,0 +1,191 @@
+package httpclient
+
+import (
+	"FastGin/pkg/config"
+	"FastGin/pkg/logg"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// 默认重试间隔
+const (
+	DefaultInitialInterval = 100 * time.Millisecond
+	DefaultMaxInterval     = 2 * time.Second
+)
+
+// idempotentMethods 可安全重试的请求方法
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+}
+
+// applyRetry 配置指数退避重试
+func applyRetry(c *req.Client, name string, cfg config.RetryConfig) {
+	if cfg.MaxAttempts <= 1 {
+		return
+	}
+	if cfg.InitialInterval <= 0 {
+		cfg.InitialInterval = DefaultInitialInterval
+	}
+	if cfg.MaxInterval <= 0 {
+		cfg.MaxInterval = DefaultMaxInterval
+	}
+
+	statuses := make(map[int]bool, len(cfg.Statuses))
+	for _, code := range cfg.Statuses {
+		statuses[code] = true
+	}
+
+	c.SetCommonRetryCount(cfg.MaxAttempts - 1).
+		SetCommonRetryInterval(func(resp *req.Response, attempt int) time.Duration {
+			return backoff(resp, attempt, cfg.InitialInterval, cfg.MaxInterval)
+		}).
+		SetCommonRetryCondition(func(resp *req.Response, err error) bool {
+			return shouldRetry(resp, err, statuses, cfg.RetryNonIdempotent)
+		}).
+		SetCommonRetryHook(func(resp *req.Response, err error) {
+			fields := map[string]interface{}{
+				"upstream": name,
+				"attempt":  resp.Request.RetryAttempt,
+			}
+			if resp.Request.RawURL != "" {
+				fields["url"] = resp.Request.RawURL
+			}
+			if err != nil {
+				fields["error"] = err.Error()
+			} else if resp.Response != nil {
+				fields["status_code"] = resp.StatusCode
+			}
+			logg.Warn("上游请求重试", fields)
+		})
+}
+
+// shouldRetry 判断是否需要重试：仅重试幂等请求的传输错误和指定状态码，熔断和超出预算时不重试
+func shouldRetry(resp *req.Response, err error, statuses map[int]bool, nonIdempotent bool) bool {
+	if errors.Is(err, ErrCircuitOpen) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if resp == nil || resp.Request == nil {
+		return false
+	}
+	r := resp.Request
+	if deadline, ok := budgetDeadline(r.Context()); ok && time.Until(deadline) <= 0 {
+		return false
+	}
+	if !nonIdempotent && !idempotentMethods[r.Method] && r.Headers.Get("Idempotency-Key") == "" {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return resp.Response != nil && statuses[resp.StatusCode]
+}
+
+// backoff 计算第attempt次重试的等待时间：指数增长并加入随机抖动，优先遵循Retry-After
+func backoff(resp *req.Response, attempt int, initial, max time.Duration) time.Duration {
+	if resp != nil && resp.Response != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			if d := time.Duration(seconds) * time.Second; d < max {
+				return d
+			}
+			return max
+		}
+	}
+
+	d := initial << (attempt - 1)
+	if d <= 0 || d > max {
+		d = max
+	}
+	// 在[d/2, d]之间随机取值，避免多个客户端同时重试
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// budgetKey 请求上下文中保存预算截止时间的键
+type budgetKey struct{}
+
+// applyBudget 为未设置截止时间的请求加上总耗时预算，重试也计入其中
+// 首次发送前只记录截止时间，每次尝试由wrapBudget按该时间创建context，尝试结束后立即释放计时器
+func applyBudget(c *req.Client, budget time.Duration) {
+	if budget <= 0 {
+		return
+	}
+	c.OnBeforeRequest(func(_ *req.Client, r *req.Request) error {
+		if r.RetryAttempt > 0 {
+			return nil
+		}
+		if _, ok := r.Context().Deadline(); ok {
+			return nil
+		}
+		// 会话登录等由同一次调用派生的请求共用调用方的预算
+		if _, ok := r.Context().Value(budgetKey{}).(time.Time); ok {
+			return nil
+		}
+		r.SetContext(context.WithValue(r.Context(), budgetKey{}, time.Now().Add(budget)))
+		return nil
+	})
+	c.WrapRoundTripFunc(wrapBudget)
+}
+
+// wrapBudget 按预算截止时间为单次尝试创建context，请求失败或响应体读完、关闭时释放
+func wrapBudget(rt req.RoundTripper) req.RoundTripFunc {
+	return func(r *req.Request) (*req.Response, error) {
+		deadline, ok := r.Context().Value(budgetKey{}).(time.Time)
+		if !ok {
+			return rt.RoundTrip(r)
+		}
+
+		parent := r.Context()
+		ctx, cancel := context.WithDeadline(parent, deadline)
+		r.SetContext(ctx)
+		resp, err := rt.RoundTrip(r)
+		r.SetContext(parent)
+
+		if err != nil || resp == nil || resp.Response == nil || resp.Body == nil {
+			cancel()
+			return resp, err
+		}
+		resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+		return resp, nil
+	}
+}
+
+// cancelBody 响应体读到结尾或关闭时释放单次尝试的context，与标准库客户端的超时处理一致
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.cancel()
+	}
+	return n, err
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// budgetDeadline 返回请求的截止时间，调用方未设置时取预算截止时间
+func budgetDeadline(ctx context.Context) (time.Time, bool) {
+	if deadline, ok := ctx.Deadline(); ok {
+		return deadline, true
+	}
+	deadline, ok := ctx.Value(budgetKey{}).(time.Time)
+	return deadline, ok
+}
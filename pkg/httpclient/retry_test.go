@@ -0,0 +1,258 @@
+package httpclient
+
+import (
+	"FastGin/pkg/config"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// countingServer 返回依次使用statuses作为状态码的测试服务器，超出后返回200
+func countingServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(hits.Add(1))
+		if n <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func newTestClient(t *testing.T, cfg config.UpstreamConfig) *Client {
+	t.Helper()
+	c, err := New("test", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+func retryConfig(statuses ...int) config.RetryConfig {
+	return config.RetryConfig{
+		MaxAttempts:     3,
+		InitialInterval: time.Millisecond,
+		MaxInterval:     5 * time.Millisecond,
+		Statuses:        statuses,
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	initial, max := 100*time.Millisecond, time.Second
+	for attempt, want := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		3: 400 * time.Millisecond,
+		4: 800 * time.Millisecond,
+		5: time.Second,
+		9: time.Second,
+	} {
+		for i := 0; i < 20; i++ {
+			if d := backoff(nil, attempt, initial, max); d < want/2 || d > want {
+				t.Fatalf("attempt %d: backoff %v, want [%v, %v]", attempt, d, want/2, want)
+			}
+		}
+	}
+}
+
+func TestBackoffRetryAfter(t *testing.T) {
+	for header, want := range map[string]time.Duration{
+		"1":  time.Second,
+		"60": 2 * time.Second, // 不超过max
+	} {
+		resp := &req.Response{Response: &http.Response{Header: http.Header{"Retry-After": {header}}}}
+		if d := backoff(resp, 1, 10*time.Millisecond, 2*time.Second); d != want {
+			t.Errorf("Retry-After %s: backoff %v, want %v", header, d, want)
+		}
+	}
+}
+
+func TestRetryStatuses(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		header   string
+		statuses []int
+		wantHits int32
+		wantCode int
+	}{
+		{"配置的状态码重试到成功", http.MethodGet, "", []int{503, 503}, 3, 200},
+		{"超过最大尝试次数", http.MethodGet, "", []int{503, 503, 503, 503}, 3, 503},
+		{"未配置的状态码不重试", http.MethodGet, "", []int{500}, 1, 500},
+		{"非幂等请求不重试", http.MethodPost, "", []int{503}, 1, 503},
+		{"带幂等键的非幂等请求重试", http.MethodPost, "k1", []int{503}, 2, 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, hits := countingServer(t, tt.statuses...)
+			c := newTestClient(t, config.UpstreamConfig{BaseURL: srv.URL, Retry: retryConfig(502, 503)})
+
+			r := c.R()
+			if tt.header != "" {
+				r.SetHeader("Idempotency-Key", tt.header)
+			}
+			resp, err := r.Send(tt.method, "/")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantCode || hits.Load() != tt.wantHits {
+				t.Fatalf("status %d hits %d, want %d and %d", resp.StatusCode, hits.Load(), tt.wantCode, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestRetryTransportError(t *testing.T) {
+	// 接受连接后立即关闭，客户端得到传输错误
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	var accepts atomic.Int32
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepts.Add(1)
+			conn.Close()
+		}
+	}()
+
+	c := newTestClient(t, config.UpstreamConfig{BaseURL: "http://" + ln.Addr().String(), Retry: retryConfig()})
+	if _, err := c.R().Get("/"); err == nil {
+		t.Fatal("expected transport error")
+	}
+	if accepts.Load() != 3 {
+		t.Fatalf("attempts %d, want 3", accepts.Load())
+	}
+}
+
+func TestBudgetExpiry(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, config.UpstreamConfig{
+		BaseURL: srv.URL,
+		Budget:  50 * time.Millisecond,
+		Retry:   retryConfig(503),
+	})
+	start := time.Now()
+	_, err := c.R().Get("/")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("budget not enforced, took %v", elapsed)
+	}
+	if hits.Load() != 1 {
+		t.Fatalf("retried after budget expired, hits %d", hits.Load())
+	}
+}
+
+func TestBudgetCoversRetries(t *testing.T) {
+	srv, hits := countingServer(t, 503, 503, 503, 503, 503)
+	c := newTestClient(t, config.UpstreamConfig{
+		BaseURL: srv.URL,
+		Budget:  30 * time.Millisecond,
+		Retry: config.RetryConfig{
+			MaxAttempts:     5,
+			InitialInterval: 20 * time.Millisecond,
+			MaxInterval:     20 * time.Millisecond,
+			Statuses:        []int{503},
+		},
+	})
+	resp, err := c.R().Get("/")
+	if err == nil && resp.StatusCode == http.StatusOK {
+		t.Fatal("expected failure within budget")
+	}
+	if hits.Load() >= 5 {
+		t.Fatalf("budget did not stop retries, hits %d", hits.Load())
+	}
+}
+
+func TestBudgetKeepsCallerDeadline(t *testing.T) {
+	srv, _ := countingServer(t)
+	c := newTestClient(t, config.UpstreamConfig{BaseURL: srv.URL, Budget: time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	resp, err := c.R().SetContext(ctx).Get("/")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("resp %v err %v", resp, err)
+	}
+	if deadline, _ := resp.Request.Context().Deadline(); !deadline.Equal(mustDeadline(t, ctx)) {
+		t.Fatal("caller deadline replaced by budget")
+	}
+}
+
+func TestBudgetReleasedAfterResponse(t *testing.T) {
+	srv, _ := countingServer(t)
+	c := newTestClient(t, config.UpstreamConfig{BaseURL: srv.URL, Budget: time.Hour})
+
+	var attempt context.Context
+	c.GetTransport().WrapRoundTripFunc(func(rt http.RoundTripper) req.HttpRoundTripFunc {
+		return func(r *http.Request) (*http.Response, error) {
+			attempt = r.Context()
+			return rt.RoundTrip(r)
+		}
+	})
+	resp, err := c.R().Get("/")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("resp %v err %v", resp, err)
+	}
+	if _, ok := attempt.Deadline(); !ok {
+		t.Fatal("attempt context has no budget deadline")
+	}
+	// 响应体已读完，预算计时器应立即释放，而不是等到一小时后到期
+	if !errors.Is(attempt.Err(), context.Canceled) {
+		t.Fatalf("attempt context err %v, want canceled", attempt.Err())
+	}
+}
+
+func mustDeadline(t *testing.T, ctx context.Context) time.Time {
+	t.Helper()
+	d, ok := ctx.Deadline()
+	if !ok {
+		t.Fatal("no deadline")
+	}
+	return d
+}
+
+func TestShouldRetryStatus(t *testing.T) {
+	statuses := map[int]bool{503: true}
+	for code, want := range map[int]bool{503: true, 500: false, 200: false} {
+		r := req.C().R()
+		r.Method = http.MethodGet
+		resp := &req.Response{Request: r, Response: &http.Response{StatusCode: code}}
+		if got := shouldRetry(resp, nil, statuses, false); got != want {
+			t.Errorf("status %s: shouldRetry %v, want %v", strconv.Itoa(code), got, want)
+		}
+	}
+	if shouldRetry(&req.Response{Request: req.C().R()}, ErrCircuitOpen, statuses, true) {
+		t.Error("circuit open should not retry")
+	}
+}
@@ -0,0 +1,402 @@
+package httpclient
+
+import (
+	"FastGin/pkg/config"
+	"FastGin/pkg/logg"
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// DefaultMaxSessions 每个上游在内存中保留的默认会话数
+const DefaultMaxSessions = 10000
+
+// sessionKey 请求上下文中保存会话的键
+type sessionKey struct{}
+
+// reloginKey 标记请求处于重新登录流程中，避免递归触发
+type reloginKey struct{}
+
+// SessionHooks 会话回调
+type SessionHooks struct {
+	// Login 建立或刷新会话，通过 s.RWithContext(ctx) 发送登录请求即可将Cookie写入会话
+	// 会话不存在、过期或上游返回鉴权失败时调用
+	Login func(ctx context.Context, s *Session) error
+	// IsAuthFailure 判断响应是否为鉴权失败，默认401和403
+	IsAuthFailure func(resp *req.Response) bool
+}
+
+// SessionManager 管理某个上游下各账号的会话
+// 内存中最多保留max_sessions个会话，超出时淘汰最久未使用的会话，持久化的会话在下次使用时重新加载
+type SessionManager struct {
+	client *Client
+	store  SessionStore
+	ttl    time.Duration
+	max    int
+	// dropOnEvict 内存存储无法在淘汰后恢复会话，淘汰时一并删除存储中的数据，避免占用无限增长
+	dropOnEvict bool
+
+	mu       sync.Mutex
+	hooks    SessionHooks
+	sessions map[string]*list.Element // 元素值为*Session
+	order    *list.List               // 队首为最近使用
+}
+
+// newSessionManager 根据配置创建会话管理器
+func newSessionManager(c *Client, cfg config.SessionConfig) (*SessionManager, error) {
+	if cfg.MaxSessions <= 0 {
+		cfg.MaxSessions = DefaultMaxSessions
+	}
+
+	dropOnEvict := false
+	var store SessionStore
+	switch cfg.Store {
+	case "", "memory":
+		store = NewMemorySessionStore()
+		dropOnEvict = true
+	case "file":
+		if cfg.Dir == "" {
+			return nil, fmt.Errorf("httpclient: file会话存储需要配置 session.dir")
+		}
+		store = NewFileSessionStore(cfg.Dir)
+	default:
+		return nil, fmt.Errorf("httpclient: 未知的会话存储 %q", cfg.Store)
+	}
+
+	return &SessionManager{
+		client:      c,
+		store:       store,
+		ttl:         cfg.TTL,
+		max:         cfg.MaxSessions,
+		dropOnEvict: dropOnEvict,
+		sessions:    make(map[string]*list.Element),
+		order:       list.New(),
+	}, nil
+}
+
+// SetStore 替换会话存储，已加载的会话不受影响
+func (m *SessionManager) SetStore(store SessionStore) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.store = store
+	m.dropOnEvict = false
+}
+
+// SetHooks 设置登录和鉴权失败判断回调
+func (m *SessionManager) SetHooks(hooks SessionHooks) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = hooks
+}
+
+// For 获取指定账号的会话，首次使用时从存储中恢复Cookie
+func (m *SessionManager) For(account string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.sessions[account]; ok {
+		m.order.MoveToFront(el)
+		return el.Value.(*Session), nil
+	}
+
+	jar, _ := cookiejar.New(nil)
+	s := &Session{
+		Client:  m.client,
+		Account: account,
+		manager: m,
+		jar:     jar,
+	}
+
+	data, err := m.store.Load(m.client.Name, account)
+	if err != nil {
+		return nil, fmt.Errorf("httpclient: 加载会话失败: %w", err)
+	}
+	if data != nil {
+		s.restore(data)
+	}
+
+	m.sessions[account] = m.order.PushFront(s)
+	for m.order.Len() > m.max {
+		m.evict(m.order.Back())
+	}
+	return s, nil
+}
+
+// Len 返回内存中的会话数
+func (m *SessionManager) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+// Remove 删除账号会话及其持久化数据
+func (m *SessionManager) Remove(account string) error {
+	m.mu.Lock()
+	if el, ok := m.sessions[account]; ok {
+		m.order.Remove(el)
+		delete(m.sessions, account)
+	}
+	store := m.store
+	m.mu.Unlock()
+
+	return store.Delete(m.client.Name, account)
+}
+
+// evict 淘汰会话，调用方需持有锁；仍在使用该会话的请求不受影响
+func (m *SessionManager) evict(el *list.Element) {
+	s := m.order.Remove(el).(*Session)
+	delete(m.sessions, s.Account)
+	if m.dropOnEvict {
+		_ = m.store.Delete(m.client.Name, s.Account)
+	}
+}
+
+// getStore 返回当前会话存储，SetStore可能与请求并发执行
+func (m *SessionManager) getStore() SessionStore {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.store
+}
+
+func (m *SessionManager) getHooks() SessionHooks {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.hooks
+}
+
+// Session 单个账号的上游会话，内嵌共享客户端，通过 R() 发出的请求自动携带并更新该账号的Cookie
+type Session struct {
+	*Client
+	Account string
+
+	manager *SessionManager
+	loginMu sync.Mutex
+
+	mu        sync.Mutex
+	jar       *cookiejar.Jar
+	cookies   []StoredCookie
+	expiresAt time.Time
+}
+
+// R 创建携带会话的请求
+func (s *Session) R() *req.Request {
+	return s.RWithContext(context.Background())
+}
+
+// RWithContext 使用指定context创建携带会话的请求
+// 登录回调中应使用回调传入的ctx，之后不要再调用SetContext替换context
+func (s *Session) RWithContext(ctx context.Context) *req.Request {
+	return s.Client.R().SetContext(context.WithValue(ctx, sessionKey{}, s))
+}
+
+// Expired 判断会话是否已过期
+func (s *Session) Expired() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.expiresAt.IsZero() && time.Now().After(s.expiresAt)
+}
+
+// Valid 判断会话是否已建立且未过期
+func (s *Session) Valid() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.cookies) > 0 && (s.expiresAt.IsZero() || time.Now().Before(s.expiresAt))
+}
+
+// Cookies 返回指定地址应携带的Cookie
+func (s *Session) Cookies(u *url.URL) []*http.Cookie {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jar.Cookies(u)
+}
+
+// SetCookies 写入Cookie并持久化
+func (s *Session) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if len(cookies) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	s.jar.SetCookies(u, cookies)
+	origin := (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/"}).String()
+	for _, ck := range cookies {
+		replaced := false
+		for i, stored := range s.cookies {
+			if stored.URL == origin && stored.Cookie.Name == ck.Name && stored.Cookie.Path == ck.Path {
+				s.cookies[i].Cookie = ck
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			s.cookies = append(s.cookies, StoredCookie{URL: origin, Cookie: ck})
+		}
+	}
+	if s.manager.ttl > 0 {
+		s.expiresAt = time.Now().Add(s.manager.ttl)
+	}
+	data := s.snapshot()
+	s.mu.Unlock()
+
+	if err := s.manager.getStore().Save(s.Name, s.Account, data); err != nil {
+		logg.Error("保存上游会话失败", map[string]interface{}{
+			"upstream": s.Name,
+			"account":  s.Account,
+			"error":    err.Error(),
+		})
+	}
+}
+
+// Reset 清空会话Cookie，下次请求前会重新登录
+func (s *Session) Reset() error {
+	s.mu.Lock()
+	s.jar, _ = cookiejar.New(nil)
+	s.cookies = nil
+	s.expiresAt = time.Time{}
+	s.mu.Unlock()
+
+	return s.manager.getStore().Delete(s.Name, s.Account)
+}
+
+// restore 从持久化数据恢复Cookie
+func (s *Session) restore(data *SessionData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, stored := range data.Cookies {
+		u, err := url.Parse(stored.URL)
+		if err != nil || stored.Cookie == nil {
+			continue
+		}
+		s.jar.SetCookies(u, []*http.Cookie{stored.Cookie})
+		s.cookies = append(s.cookies, stored)
+	}
+	s.expiresAt = data.ExpiresAt
+}
+
+// snapshot 生成持久化数据，调用方需持有锁
+func (s *Session) snapshot() *SessionData {
+	cookies := make([]StoredCookie, len(s.cookies))
+	copy(cookies, s.cookies)
+	return &SessionData{
+		Cookies:   cookies,
+		ExpiresAt: s.expiresAt,
+		UpdatedAt: time.Now(),
+	}
+}
+
+// login 调用登录回调，同一会话的并发登录只执行一次
+func (s *Session) login(ctx context.Context, force bool) error {
+	hooks := s.manager.getHooks()
+	if hooks.Login == nil {
+		return nil
+	}
+
+	s.loginMu.Lock()
+	defer s.loginMu.Unlock()
+
+	// 等待期间其他请求可能已完成登录
+	if !force && s.Valid() {
+		return nil
+	}
+
+	logg.Info("上游会话登录", map[string]interface{}{
+		"upstream": s.Name,
+		"account":  s.Account,
+	})
+	return hooks.Login(context.WithValue(ctx, reloginKey{}, true), s)
+}
+
+// wrap 会话客户端中间件：为携带会话的请求注入Cookie、保存Set-Cookie，
+// 会话过期时先登录，上游返回鉴权失败时重新登录并重试一次
+func (m *SessionManager) wrap(rt req.RoundTripper) req.RoundTripFunc {
+	return func(r *req.Request) (*req.Response, error) {
+		s, ok := r.Context().Value(sessionKey{}).(*Session)
+		if !ok {
+			return rt.RoundTrip(r)
+		}
+		inLogin := r.Context().Value(reloginKey{}) != nil
+
+		if !inLogin && !s.Valid() {
+			if err := s.login(r.Context(), false); err != nil {
+				return &req.Response{Request: r, Err: err}, err
+			}
+		}
+
+		explicit := append([]*http.Cookie(nil), r.Cookies...)
+		resp, err := s.send(rt, r, explicit)
+		if err != nil || inLogin {
+			return resp, err
+		}
+
+		hooks := m.getHooks()
+		isAuthFailure := hooks.IsAuthFailure
+		if isAuthFailure == nil {
+			isAuthFailure = defaultAuthFailure
+		}
+		if hooks.Login == nil || !isAuthFailure(resp) {
+			return resp, err
+		}
+
+		logg.Warn("上游会话鉴权失败，重新登录", map[string]interface{}{
+			"upstream":    s.Name,
+			"account":     s.Account,
+			"status_code": resp.StatusCode,
+		})
+		// 丢弃鉴权失败的响应，释放连接后再重试
+		if resp.Body != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+			resp.Body.Close()
+		}
+		if err := s.Reset(); err != nil {
+			return &req.Response{Request: r, Err: err}, err
+		}
+		if err := s.login(r.Context(), true); err != nil {
+			return &req.Response{Request: r, Err: err}, err
+		}
+		return s.send(rt, r, explicit)
+	}
+}
+
+// send 注入会话Cookie后发送，并保存响应中的Set-Cookie
+// explicit 为请求上显式设置的Cookie，同名时优先于会话Cookie
+func (s *Session) send(rt req.RoundTripper, r *req.Request, explicit []*http.Cookie) (*req.Response, error) {
+	r.Cookies = append([]*http.Cookie(nil), explicit...)
+	for _, ck := range s.Cookies(r.URL) {
+		if !hasCookie(explicit, ck.Name) {
+			r.Cookies = append(r.Cookies, ck)
+		}
+	}
+
+	resp, err := rt.RoundTrip(r)
+	if err == nil && resp != nil && resp.Response != nil {
+		s.SetCookies(r.URL, resp.Cookies())
+	}
+	return resp, err
+}
+
+func hasCookie(cookies []*http.Cookie, name string) bool {
+	for _, ck := range cookies {
+		if ck.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// maxDrainBytes 丢弃响应时最多读取的字节数，超出时直接关闭连接
+const maxDrainBytes = 64 << 10
+
+// defaultAuthFailure 默认以401和403判断鉴权失败
+func defaultAuthFailure(resp *req.Response) bool {
+	return resp != nil && resp.Response != nil &&
+		(resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden)
+}
@@ -0,0 +1,123 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// StoredCookie 持久化的Cookie，记录其来源地址以便恢复
+type StoredCookie struct {
+	URL    string       `json:"url"`
+	Cookie *http.Cookie `json:"cookie"`
+}
+
+// SessionData 会话持久化数据
+type SessionData struct {
+	Cookies   []StoredCookie `json:"cookies"`
+	ExpiresAt time.Time      `json:"expires_at,omitempty"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// SessionStore 会话存储接口，可按需实现数据库等存储
+// Load 在会话不存在时返回 nil, nil
+type SessionStore interface {
+	Load(upstream, account string) (*SessionData, error)
+	Save(upstream, account string, data *SessionData) error
+	Delete(upstream, account string) error
+}
+
+// MemorySessionStore 内存会话存储，进程重启后丢失
+type MemorySessionStore struct {
+	mu   sync.RWMutex
+	data map[string]*SessionData
+}
+
+// NewMemorySessionStore 创建内存会话存储
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{data: make(map[string]*SessionData)}
+}
+
+func (s *MemorySessionStore) Load(upstream, account string) (*SessionData, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data[upstream+"/"+account], nil
+}
+
+func (s *MemorySessionStore) Save(upstream, account string, data *SessionData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[upstream+"/"+account] = data
+	return nil
+}
+
+func (s *MemorySessionStore) Delete(upstream, account string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, upstream+"/"+account)
+	return nil
+}
+
+// FileSessionStore 文件会话存储，每个账号一个JSON文件：<dir>/<upstream>/<account>.json
+type FileSessionStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileSessionStore 创建文件会话存储
+func NewFileSessionStore(dir string) *FileSessionStore {
+	return &FileSessionStore{dir: dir}
+}
+
+func (s *FileSessionStore) path(upstream, account string) string {
+	return filepath.Join(s.dir, url.PathEscape(upstream), url.PathEscape(account)+".json")
+}
+
+func (s *FileSessionStore) Load(upstream, account string) (*SessionData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	raw, err := os.ReadFile(s.path(upstream, account))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data := new(SessionData)
+	if err := json.Unmarshal(raw, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *FileSessionStore) Save(upstream, account string, data *SessionData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.path(upstream, account)
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, raw, 0600)
+}
+
+func (s *FileSessionStore) Delete(upstream, account string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := os.Remove(s.path(upstream, account))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
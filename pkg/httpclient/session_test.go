@@ -0,0 +1,94 @@
+package httpclient
+
+import (
+	"FastGin/pkg/config"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSessionEviction(t *testing.T) {
+	c := newTestClient(t, config.UpstreamConfig{Session: config.SessionConfig{MaxSessions: 2}})
+	m := c.Sessions()
+
+	a, _ := m.For("a")
+	m.For("b")
+	m.For("a") // a 变为最近使用
+	m.For("c") // 淘汰 b
+
+	if m.Len() != 2 {
+		t.Fatalf("len %d, want 2", m.Len())
+	}
+	if again, _ := m.For("a"); again != a {
+		t.Fatal("recently used session was evicted")
+	}
+	for i := 0; i < 100; i++ {
+		m.For("user" + strconv.Itoa(i))
+	}
+	if m.Len() != 2 {
+		t.Fatalf("len %d, want 2", m.Len())
+	}
+}
+
+func TestSessionEvictionDropsMemoryStore(t *testing.T) {
+	c := newTestClient(t, config.UpstreamConfig{Session: config.SessionConfig{MaxSessions: 1}})
+	store := c.Sessions().store.(*MemorySessionStore)
+
+	s, _ := c.Session("a")
+	s.SetCookies(mustParseURL(t, "http://example.com/"), []*http.Cookie{{Name: "sid", Value: "1"}})
+	c.Session("b")
+
+	if data, _ := store.Load("test", "a"); data != nil {
+		t.Fatal("evicted session still held by memory store")
+	}
+}
+
+func TestSessionRelogin(t *testing.T) {
+	var hits, logins atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			logins.Add(1)
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "fresh"})
+		default:
+			hits.Add(1)
+			if ck, err := r.Cookie("sid"); err != nil || ck.Value != "fresh" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte("need login"))
+				return
+			}
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, config.UpstreamConfig{BaseURL: srv.URL})
+	c.Sessions().SetHooks(SessionHooks{
+		Login: func(ctx context.Context, s *Session) error {
+			return s.Check(s.RWithContext(ctx).Post("/login"))
+		},
+	})
+	s, _ := c.Session("a")
+	s.SetCookies(mustParseURL(t, srv.URL), []*http.Cookie{{Name: "sid", Value: "stale"}})
+
+	resp, err := s.R().Get("/orders")
+	if err != nil || resp.String() != "ok" {
+		t.Fatalf("resp %v err %v", resp, err)
+	}
+	if hits.Load() != 2 || logins.Load() != 1 {
+		t.Fatalf("hits %d logins %d, want 2 and 1", hits.Load(), logins.Load())
+	}
+}
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
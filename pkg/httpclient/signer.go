@@ -0,0 +1,217 @@
+package httpclient
+
+import (
+	"FastGin/pkg/config"
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// Payload 待签名的请求内容
+// Params 为表单参数（表单请求）或查询参数（其他请求），已包含时间戳和随机串
+type Payload struct {
+	Method    string
+	Path      string
+	Params    url.Values
+	Body      []byte
+	Timestamp string
+	Nonce     string
+}
+
+// Signer 请求签名器
+type Signer interface {
+	Sign(p *Payload) (string, error)
+}
+
+// SignerFunc 函数形式的签名器
+type SignerFunc func(p *Payload) (string, error)
+
+// Sign 实现Signer接口
+func (f SignerFunc) Sign(p *Payload) (string, error) {
+	return f(p)
+}
+
+var (
+	signersMu sync.RWMutex
+	signers   = map[string]func(secret string) Signer{
+		"hmac-sha256": HMACSHA256,
+		"md5-sorted":  MD5Sorted,
+	}
+)
+
+// RegisterSigner 注册签名算法，供配置中的sign.type引用
+func RegisterSigner(name string, factory func(secret string) Signer) {
+	signersMu.Lock()
+	defer signersMu.Unlock()
+	signers[name] = factory
+}
+
+// HMACSHA256 以HMAC-SHA256对规范化串签名，输出小写十六进制
+// 规范化串：排序后的参数串，请求体非空时追加 "&body=<请求体>"
+func HMACSHA256(secret string) Signer {
+	return SignerFunc(func(p *Payload) (string, error) {
+		canonical := SortedParams(p.Params)
+		if len(p.Body) > 0 {
+			canonical += "&body=" + string(p.Body)
+		}
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(canonical))
+		return hex.EncodeToString(mac.Sum(nil)), nil
+	})
+}
+
+// MD5Sorted 对 "排序后的参数串&key=<密钥>" 取MD5，输出小写十六进制
+func MD5Sorted(secret string) Signer {
+	return SignerFunc(func(p *Payload) (string, error) {
+		sum := md5.Sum([]byte(SortedParams(p.Params) + "&key=" + secret))
+		return hex.EncodeToString(sum[:]), nil
+	})
+}
+
+// SortedParams 按键名排序拼接为 k1=v1&k2=v2，值不做URL编码
+func SortedParams(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf strings.Builder
+	for _, k := range keys {
+		for _, v := range params[k] {
+			if buf.Len() > 0 {
+				buf.WriteByte('&')
+			}
+			buf.WriteString(k)
+			buf.WriteByte('=')
+			buf.WriteString(v)
+		}
+	}
+	return buf.String()
+}
+
+// signMiddleware 签名客户端中间件，每次发送（含重试）都会重新生成时间戳、随机串和签名
+type signMiddleware struct {
+	cfg    config.SignConfig
+	signer Signer
+}
+
+// newSignMiddleware 根据配置创建签名中间件，未配置签名算法时返回nil
+func newSignMiddleware(cfg config.SignConfig) (*signMiddleware, error) {
+	if cfg.Type == "" {
+		return nil, nil
+	}
+
+	signersMu.RLock()
+	factory, ok := signers[cfg.Type]
+	signersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("httpclient: 未知的签名算法 %q", cfg.Type)
+	}
+	// 空密钥生成的签名任何人都能伪造，配置了签名算法时必须提供secret
+	if cfg.Secret == "" {
+		return nil, fmt.Errorf("httpclient: 签名算法 %s 缺少secret", cfg.Type)
+	}
+
+	if cfg.SignParam == "" {
+		cfg.SignParam = "sign"
+	}
+	if cfg.TimestampParam == "" {
+		cfg.TimestampParam = "t"
+	}
+	return &signMiddleware{cfg: cfg, signer: factory(cfg.Secret)}, nil
+}
+
+// signParams 返回签名中间件生成的参数名，这些参数的取值每次请求都会变化
+func signParams(m *signMiddleware) []string {
+	if m == nil {
+		return nil
+	}
+	return []string{m.cfg.SignParam, m.cfg.TimestampParam, m.cfg.NonceParam}
+}
+
+// wrap 在发送前为请求签名：表单请求写入表单，其他请求写入查询参数
+func (m *signMiddleware) wrap(rt req.RoundTripper) req.RoundTripFunc {
+	return func(r *req.Request) (*req.Response, error) {
+		if err := m.sign(r); err != nil {
+			return &req.Response{Request: r, Err: err}, err
+		}
+		return rt.RoundTrip(r)
+	}
+}
+
+func (m *signMiddleware) sign(r *req.Request) error {
+	isForm := len(r.FormData) > 0
+	params := r.FormData
+	if !isForm {
+		params = r.URL.Query()
+	}
+	params.Del(m.cfg.SignParam)
+
+	p := &Payload{
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Params:    params,
+		Timestamp: m.timestamp(),
+	}
+	params.Set(m.cfg.TimestampParam, p.Timestamp)
+	if m.cfg.NonceParam != "" {
+		nonce, err := Nonce()
+		if err != nil {
+			return err
+		}
+		p.Nonce = nonce
+		params.Set(m.cfg.NonceParam, nonce)
+	}
+	if !isForm && len(r.Body) > 0 {
+		p.Body = bytes.Clone(r.Body)
+	}
+
+	signature, err := m.signer.Sign(p)
+	if err != nil {
+		return fmt.Errorf("httpclient: 请求签名失败: %w", err)
+	}
+
+	if m.cfg.Header != "" {
+		r.Headers.Set(m.cfg.Header, signature)
+	} else {
+		params.Set(m.cfg.SignParam, signature)
+	}
+
+	if isForm {
+		r.SetBodyBytes([]byte(params.Encode()))
+	} else {
+		r.URL.RawQuery = params.Encode()
+	}
+	return nil
+}
+
+// timestamp 按配置的单位生成当前时间戳
+func (m *signMiddleware) timestamp() string {
+	now := time.Now()
+	if m.cfg.TimestampUnit == "s" {
+		return strconv.FormatInt(now.Unix(), 10)
+	}
+	return strconv.FormatInt(now.UnixMilli(), 10)
+}
+
+// Nonce 生成32位十六进制随机串
+func Nonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,150 @@
+package httpclient
+
+import (
+	"FastGin/pkg/config"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// 已知向量由独立实现（Python hashlib/hmac）计算
+var signVectors = []struct {
+	name   string
+	signer func(string) Signer
+	body   string
+	want   string
+}{
+	{"hmac-sha256", HMACSHA256, "", "f6b8221189ccc4951249d738fb700c57340d3088591ce146cca6e3e357d7d4c1"},
+	{"hmac-sha256 带请求体", HMACSHA256, `{"amount":100}`, "f99831e5d22c43c0ddc1bff56bec517a1fdc82756e5fd69ffd8ca7cf1a5fbe76"},
+	{"md5-sorted", MD5Sorted, "", "e85790896213e890b0c85cacbeb1283f"},
+	{"md5-sorted 忽略请求体", MD5Sorted, `{"amount":100}`, "e85790896213e890b0c85cacbeb1283f"},
+}
+
+func TestSignVectors(t *testing.T) {
+	for _, v := range signVectors {
+		t.Run(v.name, func(t *testing.T) {
+			p := &Payload{
+				Method: http.MethodPost,
+				Path:   "/resource/core/v1/order/create",
+				Params: url.Values{"uid": {"1001"}, "cid": {"210101"}, "t": {"1700000000000"}},
+				Body:   []byte(v.body),
+			}
+			got, err := v.signer("secret").Sign(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != v.want {
+				t.Fatalf("got %s, want %s", got, v.want)
+			}
+		})
+	}
+}
+
+func TestHMACSHA256Empty(t *testing.T) {
+	got, _ := HMACSHA256("secret").Sign(&Payload{})
+	if want := "f9e66e179b6747ae54108f82f8ade8b3c25d76fd30afde6c395822c530196169"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestSortedParams(t *testing.T) {
+	tests := []struct {
+		params url.Values
+		want   string
+	}{
+		{nil, ""},
+		{url.Values{"b": {"2"}, "a": {"1"}}, "a=1&b=2"},
+		{url.Values{"a": {"3", "1"}, "B": {"x"}}, "B=x&a=3&a=1"}, // 大写在前，同名参数保持原顺序
+		{url.Values{"q": {"a b&c=d"}}, "q=a b&c=d"},              // 值不做URL编码
+		{url.Values{"empty": {""}}, "empty="},
+	}
+	for _, tt := range tests {
+		if got := SortedParams(tt.params); got != tt.want {
+			t.Errorf("SortedParams(%v) = %q, want %q", tt.params, got, tt.want)
+		}
+	}
+}
+
+// signedRequest 测试服务器收到的请求参数、签名请求头和请求体
+type signedRequest struct {
+	params url.Values
+	header string
+	body   string
+}
+
+func signServer(t *testing.T) (*httptest.Server, *signedRequest) {
+	t.Helper()
+	got := &signedRequest{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got.body = string(body)
+		got.header = r.Header.Get("X-Sign")
+		got.params = r.URL.Query()
+		if r.Header.Get("Content-Type") == "application/x-www-form-urlencoded" {
+			got.params, _ = url.ParseQuery(got.body)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, got
+}
+
+func TestSignMiddlewareForm(t *testing.T) {
+	srv, got := signServer(t)
+	c := newTestClient(t, config.UpstreamConfig{
+		BaseURL: srv.URL,
+		Sign:    config.SignConfig{Type: "md5-sorted", Secret: "secret", NonceParam: "nonce", TimestampUnit: "s"},
+	})
+
+	if _, err := c.R().SetFormData(map[string]string{"uid": "1001", "cid": "210101"}).Post("/order"); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.params.Get("t")) != 10 || len(got.params.Get("nonce")) != 32 {
+		t.Fatalf("missing timestamp or nonce: %v", got.params)
+	}
+	sign := got.params.Get("sign")
+	got.params.Del("sign")
+	want, _ := MD5Sorted("secret").Sign(&Payload{Params: got.params})
+	if sign != want {
+		t.Fatalf("form sign %s, want %s", sign, want)
+	}
+}
+
+func TestSignMiddlewareJSON(t *testing.T) {
+	srv, got := signServer(t)
+	c := newTestClient(t, config.UpstreamConfig{
+		BaseURL: srv.URL,
+		Sign:    config.SignConfig{Type: "hmac-sha256", Secret: "secret", TimestampParam: "ts", Header: "X-Sign"},
+	})
+
+	body := `{"amount":100}`
+	if _, err := c.R().SetHeader("Content-Type", "application/json").SetBodyString(body).SetQueryParam("uid", "1001").Post("/pay"); err != nil {
+		t.Fatal(err)
+	}
+	if got.body != body || got.params.Get("sign") != "" || len(got.params.Get("ts")) != 13 {
+		t.Fatalf("unexpected request: params %v body %s", got.params, got.body)
+	}
+
+	// 按规范化串独立计算：排序后的查询参数&body=<请求体>
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("ts=" + got.params.Get("ts") + "&uid=1001&body=" + body))
+	if want := hex.EncodeToString(mac.Sum(nil)); got.header != want {
+		t.Fatalf("header sign %s, want %s", got.header, want)
+	}
+}
+
+func TestSignMiddlewareUnknownType(t *testing.T) {
+	if _, err := New("test", config.UpstreamConfig{Sign: config.SignConfig{Type: "rot13"}}); err == nil {
+		t.Fatal("expected error for unknown sign type")
+	}
+}
+
+func TestSignMiddlewareRequiresSecret(t *testing.T) {
+	if _, err := New("test", config.UpstreamConfig{Sign: config.SignConfig{Type: "md5-sorted"}}); err == nil {
+		t.Fatal("signing without secret accepted")
+	}
+}
@@ -0,0 +1,70 @@
+package httpclient
+
+import (
+	"FastGin/pkg/tracing"
+	"net/http"
+	"strconv"
+
+	"github.com/imroc/req/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingMiddleware 为每次出站请求尝试创建客户端span并注入W3C追踪头，位于最外层，会话重新登录在该span之内。
+// req的重试循环在中间件链之外，每次重试单独创建span并记录重试序号，各次尝试的span都以调用方的上下文为父级
+type tracingMiddleware struct {
+	upstream string
+}
+
+func (m *tracingMiddleware) wrap(rt req.RoundTripper) req.RoundTripFunc {
+	return func(r *req.Request) (*req.Response, error) {
+		// 返回前恢复调用方的上下文，避免重试时以本次已结束的span为父级
+		parent := r.Context()
+		defer r.SetContext(parent)
+
+		ctx, span := tracing.Tracer().Start(parent, r.Method+" "+m.upstream,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.PeerService(m.upstream),
+			))
+		defer span.End()
+
+		if r.RetryAttempt > 0 {
+			span.SetAttributes(semconv.HTTPRequestResendCount(r.RetryAttempt))
+		}
+		if r.URL != nil {
+			span.SetAttributes(semconv.ServerAddress(r.URL.Hostname()), semconv.URLPath(r.URL.Path))
+			if port, err := strconv.Atoi(r.URL.Port()); err == nil {
+				span.SetAttributes(semconv.ServerPort(port))
+			}
+		}
+		if r.Headers == nil {
+			r.Headers = make(http.Header)
+		}
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(r.Headers))
+		r.SetContext(ctx)
+
+		resp, err := rt.RoundTrip(r)
+		result := outcome(resp, err)
+		span.SetAttributes(attributeOutcome.String(result))
+		switch {
+		case err != nil:
+			span.RecordError(err)
+			span.SetStatus(codes.Error, result)
+		case resp != nil && resp.Response != nil:
+			span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+			if resp.StatusCode >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+			}
+		}
+		return resp, err
+	}
+}
+
+// attributeOutcome 出站调用结果，取值与 upstream_requests_total 的 outcome 标签一致
+const attributeOutcome = attribute.Key("upstream.outcome")
@@ -0,0 +1,157 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+// ContextKey 当前请求语言在gin.Context中的键
+const ContextKey = "Locale"
+
+// DefaultLocale 默认语言
+const DefaultLocale = "zh-CN"
+
+//go:embed locales/*
+var embedded embed.FS
+
+var (
+	mu       sync.RWMutex
+	catalogs = make(map[string]map[string]string)
+	tags     []language.Tag
+	matcher  language.Matcher
+)
+
+func init() {
+	if err := Load(embedded); err != nil {
+		panic(fmt.Sprintf("i18n: 加载内置语言包失败: %v", err))
+	}
+}
+
+// Load 从文件系统中加载语言包，文件名即语言标签，如 en-US.yaml、zh-CN.json
+// 同一语言的多个文件会合并，后加载的同名键覆盖先加载的
+func Load(fsys fs.FS) error {
+	return fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		ext := path.Ext(p)
+		var unmarshal func([]byte, interface{}) error
+		switch ext {
+		case ".yaml", ".yml":
+			unmarshal = yaml.Unmarshal
+		case ".json":
+			unmarshal = json.Unmarshal
+		default:
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		messages := make(map[string]string)
+		if err := unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("解析语言包 %s 失败: %w", p, err)
+		}
+
+		Register(strings.TrimSuffix(path.Base(p), ext), messages)
+		return nil
+	})
+}
+
+// Register 注册或合并指定语言的消息
+func Register(locale string, messages map[string]string) {
+	tag := language.Make(locale)
+	locale = tag.String()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	catalog, ok := catalogs[locale]
+	if !ok {
+		catalog = make(map[string]string, len(messages))
+		catalogs[locale] = catalog
+		tags = append(tags, tag)
+		// 默认语言始终排在首位，作为无法匹配时的回退
+		for i, t := range tags {
+			if t.String() == DefaultLocale {
+				tags[0], tags[i] = tags[i], tags[0]
+			}
+		}
+		matcher = language.NewMatcher(tags)
+	}
+	for k, v := range messages {
+		catalog[k] = v
+	}
+}
+
+// Negotiate 根据Accept-Language请求头协商出已支持的语言
+func Negotiate(acceptLanguage string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if matcher == nil || acceptLanguage == "" {
+		return DefaultLocale
+	}
+
+	prefs, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(prefs) == 0 {
+		return DefaultLocale
+	}
+	_, index, confidence := matcher.Match(prefs...)
+	if confidence == language.No {
+		return DefaultLocale
+	}
+	return tags[index].String()
+}
+
+// Locale 获取当前请求的语言
+func Locale(c *gin.Context) string {
+	if locale := c.GetString(ContextKey); locale != "" {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// Translate 在指定语言中查找消息，找不到时依次回退到默认语言和fallback
+// args 按 {name} 占位符替换
+func Translate(locale, key, fallback string, args map[string]string) string {
+	msg, ok := lookup(locale, key)
+	if !ok {
+		msg, ok = lookup(DefaultLocale, key)
+	}
+	if !ok {
+		msg = fallback
+	}
+	for name, value := range args {
+		msg = strings.ReplaceAll(msg, "{"+name+"}", value)
+	}
+	return msg
+}
+
+// T 按当前请求语言翻译消息
+func T(c *gin.Context, key, fallback string) string {
+	return Translate(Locale(c), key, fallback, nil)
+}
+
+func lookup(locale, key string) (string, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	catalog, ok := catalogs[locale]
+	if !ok {
+		return "", false
+	}
+	msg, ok := catalog[key]
+	return msg, ok
+}
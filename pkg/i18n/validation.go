@@ -0,0 +1,17 @@
+package i18n
+
+import "github.com/go-playground/validator/v10"
+
+// FieldMessage 翻译单个字段的校验错误
+// 字段显示名取 field.<字段名>，规则模板取 validation.<tag>，找不到时使用 validation.default
+func FieldMessage(locale string, fe validator.FieldError) string {
+	field := Translate(locale, "field."+fe.Field(), fe.Field(), nil)
+	args := map[string]string{
+		"field": field,
+		"param": fe.Param(),
+		"rule":  fe.Tag(),
+	}
+
+	fallback := Translate(locale, "validation.default", "{field} 校验失败", args)
+	return Translate(locale, "validation."+fe.Tag(), fallback, args)
+}
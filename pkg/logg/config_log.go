@@ -1,6 +1,7 @@
 package logg
 
 import (
+	"FastGin/pkg/metrics"
 	"bufio"
 	"encoding/json"
 	"fmt"
@@ -212,6 +213,8 @@ func InitLogger(cfg Config) error {
 
 	// 添加caller hook
 	Log.Hooks.Add(&callerHook{})
+	// 按级别统计日志条数
+	Log.AddHook(&metricsHook{})
 	fileLog.Hooks.Add(&callerHook{})
 
 	go checkLogFileDaily()
@@ -253,6 +256,21 @@ func (h *writeHook) Fire(entry *logrus.Entry) error {
 	return nil
 }
 
+// logEntries 按级别统计的日志条数
+var logEntries = metrics.NewCounterVec("log_entries_total", "按级别统计的日志条数", "level")
+
+// metricsHook 用于按级别统计日志条数
+type metricsHook struct{}
+
+func (h *metricsHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *metricsHook) Fire(entry *logrus.Entry) error {
+	logEntries.WithLabelValues(entry.Level.String()).Inc()
+	return nil
+}
+
 // callerHook 用于获取正确的调用者信息
 type callerHook struct{}
 
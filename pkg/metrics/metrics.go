@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DefBuckets 默认的耗时直方图分桶，单位秒
+var DefBuckets = prometheus.DefBuckets
+
+// registry 本服务的指标注册表，只包含这里注册的指标，不混入依赖库注册到默认注册表的指标
+var registry = prometheus.NewRegistry()
+
+// factory 创建指标并注册到registry，名称无效或重复时panic，属于编码错误
+var factory = promauto.With(registry)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler 指标抓取接口，按抓取方的Accept协商输出格式
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		// 个别指标采集失败时仍输出其余指标
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+// NewCounter 创建并注册没有标签的计数器
+func NewCounter(name, help string) prometheus.Counter {
+	return factory.NewCounter(prometheus.CounterOpts{Name: name, Help: help})
+}
+
+// NewCounterVec 创建并注册计数器，labels为标签名，通过 WithLabelValues 获取时间序列
+func NewCounterVec(name, help string, labels ...string) *prometheus.CounterVec {
+	return factory.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labels)
+}
+
+// NewGaugeVec 创建并注册仪表
+func NewGaugeVec(name, help string, labels ...string) *prometheus.GaugeVec {
+	return factory.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, labels)
+}
+
+// NewHistogramVec 创建并注册直方图，buckets为升序的分桶上界，为空时使用DefBuckets
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *prometheus.HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	return factory.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: help, Buckets: buckets}, labels)
+}
+
+// NewGaugeFunc 注册在抓取时计算的仪表，例如队列长度或缓存条目数
+func NewGaugeFunc(name, help string, fn func() float64) {
+	factory.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help}, fn)
+}
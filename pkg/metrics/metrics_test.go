@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// scrape 抓取指标接口并按Prometheus文本格式解析
+func scrape(t *testing.T) map[string]*dto.MetricFamily {
+	t.Helper()
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d", w.Code)
+	}
+	if got := expfmt.ResponseFormat(w.Header()); got.FormatType() != expfmt.TypeTextPlain {
+		t.Fatalf("format %s, want text", got)
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(w.Body)
+	if err != nil {
+		t.Fatalf("parse exposition: %v", err)
+	}
+	return families
+}
+
+// labelValue 返回样本中指定标签的值
+func labelValue(m *dto.Metric, name string) string {
+	for _, l := range m.GetLabel() {
+		if l.GetName() == name {
+			return l.GetValue()
+		}
+	}
+	return ""
+}
+
+func TestExposition(t *testing.T) {
+	requests := NewCounterVec("test_requests_total", "测试请求数", "route")
+	requests.WithLabelValues(`/a"b\c`).Add(2)
+	duration := NewHistogramVec("test_duration_seconds", "测试耗时", []float64{0.1, 1})
+	duration.WithLabelValues().Observe(0.5)
+	NewGaugeFunc("test_queue_length", "测试队列长度", func() float64 { return 3 })
+
+	families := scrape(t)
+
+	counter := families["test_requests_total"]
+	if counter.GetType() != dto.MetricType_COUNTER || len(counter.GetMetric()) != 1 {
+		t.Fatalf("counter %v", counter)
+	}
+	// 标签值中的引号和反斜杠经过转义后能原样解析回来
+	if m := counter.GetMetric()[0]; labelValue(m, "route") != `/a"b\c` || m.GetCounter().GetValue() != 2 {
+		t.Fatalf("counter sample %v", m)
+	}
+
+	histogram := families["test_duration_seconds"].GetMetric()[0].GetHistogram()
+	if histogram.GetSampleCount() != 1 || histogram.GetSampleSum() != 0.5 {
+		t.Fatalf("histogram %v", histogram)
+	}
+	// 分桶为累计值：0.5落在le=1，不在le=0.1，最后是+Inf分桶
+	if b := histogram.GetBucket(); len(b) != 3 || b[0].GetCumulativeCount() != 0 || b[1].GetCumulativeCount() != 1 || b[2].GetCumulativeCount() != 1 {
+		t.Fatalf("buckets %v", b)
+	}
+
+	if g := families["test_queue_length"]; g.GetType() != dto.MetricType_GAUGE || g.GetMetric()[0].GetGauge().GetValue() != 3 {
+		t.Fatalf("gauge func %v", g)
+	}
+
+	// 运行时和进程指标由官方采集器提供
+	for _, name := range []string{"go_goroutines", "go_gc_duration_seconds", "process_start_time_seconds"} {
+		if families[name] == nil {
+			t.Errorf("missing %s", name)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package rbac
+
+import (
+	"FastGin/pkg/auth"
+	"FastGin/pkg/errcode"
+	"FastGin/pkg/logg"
+	"context"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// std 默认权限检查器，Init之前拒绝全部请求
+var std = NewEnforcer(nil)
+
+// Init 设置默认权限检查器的策略来源并加载，应在启动阶段、注册路由之前调用
+func Init(ctx context.Context, loader Loader) error {
+	std.mu.Lock()
+	std.loader = loader
+	std.mu.Unlock()
+	return std.Reload(ctx)
+}
+
+// Default 返回默认权限检查器
+func Default() *Enforcer {
+	return std
+}
+
+// Reload 重新加载默认权限检查器的策略
+func Reload(ctx context.Context) error {
+	return std.Reload(ctx)
+}
+
+// Check 使用默认权限检查器检查主体是否拥有指定权限
+func Check(subject, permission string) Decision {
+	return std.Check(subject, permission)
+}
+
+// Require 使用默认权限检查器的权限中间件，需放在认证中间件之后
+//
+//	apiGroup.POST("/orders", rbac.Require("order:create"), errcode.Handle(handler.CreateOrder))
+func Require(permissions ...string) gin.HandlerFunc {
+	return std.Require(permissions...)
+}
+
+// Require 权限中间件，要求当前认证主体同时拥有全部指定权限
+// 未认证返回errcode.Unauthorized，缺少任一权限返回errcode.Forbidden
+func (e *Enforcer) Require(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subject := auth.Principal(c)
+		if subject == "" {
+			errcode.SendError(c, errcode.Unauthorized)
+			return
+		}
+
+		for _, perm := range permissions {
+			d := e.Check(subject, perm)
+			if d.Allowed {
+				continue
+			}
+			logg.Warn("权限不足", map[string]interface{}{
+				"principal":  subject,
+				"permission": perm,
+				"roles":      d.Roles,
+				"reason":     d.Reason,
+				"path":       c.Request.URL.Path,
+			})
+			errcode.SendError(c, errcode.Forbidden.Wrap(fmt.Errorf("rbac: %s 缺少权限 %s", subject, perm)))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,257 @@
+package rbac
+
+import (
+	"FastGin/pkg/config"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Policy 角色和角色绑定
+type Policy struct {
+	Roles    []config.RoleConfig
+	Bindings []config.RoleBinding
+}
+
+// Loader 策略来源，配置文件之外也可以从数据库等存储加载
+type Loader interface {
+	Load(ctx context.Context) (*Policy, error)
+}
+
+// LoaderFunc 函数形式的策略来源
+type LoaderFunc func(ctx context.Context) (*Policy, error)
+
+// Load 实现Loader接口
+func (f LoaderFunc) Load(ctx context.Context) (*Policy, error) {
+	return f(ctx)
+}
+
+// FromConfig 从配置文件加载策略
+func FromConfig(cfg config.RBACConfig) Loader {
+	return LoaderFunc(func(context.Context) (*Policy, error) {
+		return &Policy{Roles: cfg.Roles, Bindings: cfg.Bindings}, nil
+	})
+}
+
+// Decision 一次权限检查的结果及原因，便于排查拒绝访问的问题
+type Decision struct {
+	Subject    string   `json:"subject"`
+	Permission string   `json:"permission"`
+	Allowed    bool     `json:"allowed"`
+	Roles      []string `json:"roles"`          // 主体拥有的全部角色，包括继承的角色
+	Role       string   `json:"role,omitempty"` // 授予权限的角色
+	Rule       string   `json:"rule,omitempty"` // 命中的权限规则
+	Reason     string   `json:"reason"`
+}
+
+// Enforcer 权限检查器，策略可在运行时重新加载
+type Enforcer struct {
+	mu     sync.Mutex
+	loader Loader
+	policy atomic.Pointer[compiled]
+}
+
+// NewEnforcer 创建权限检查器，加载策略前拒绝全部请求
+func NewEnforcer(loader Loader) *Enforcer {
+	e := &Enforcer{loader: loader}
+	e.policy.Store(&compiled{})
+	return e
+}
+
+// Reload 从策略来源重新加载，策略无效时保留原有策略
+func (e *Enforcer) Reload(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.loader == nil {
+		return fmt.Errorf("rbac: 未配置策略来源")
+	}
+	p, err := e.loader.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("rbac: 加载策略失败: %w", err)
+	}
+	c, err := compile(p)
+	if err != nil {
+		return err
+	}
+	e.policy.Store(c)
+	return nil
+}
+
+// Check 检查主体是否拥有指定权限
+func (e *Enforcer) Check(subject, permission string) Decision {
+	return e.policy.Load().check(subject, permission)
+}
+
+// compiled 展开继承关系后的策略
+type compiled struct {
+	roles    map[string][]string // 角色 -> 自身及继承的全部权限规则，按角色名顺序排列
+	closure  map[string][]string // 角色 -> 自身及继承的全部角色名
+	bindings []config.RoleBinding
+}
+
+// compile 校验并展开策略，引用不存在的角色或存在循环继承时返回错误
+func compile(p *Policy) (*compiled, error) {
+	defs := make(map[string]config.RoleConfig, len(p.Roles))
+	for _, r := range p.Roles {
+		if r.Name == "" {
+			return nil, fmt.Errorf("rbac: 角色名不能为空")
+		}
+		if _, ok := defs[r.Name]; ok {
+			return nil, fmt.Errorf("rbac: 角色 %s 重复定义", r.Name)
+		}
+		for _, perm := range r.Permissions {
+			if perm == "" {
+				return nil, fmt.Errorf("rbac: 角色 %s 包含空权限", r.Name)
+			}
+		}
+		defs[r.Name] = r
+	}
+
+	c := &compiled{
+		roles:    make(map[string][]string, len(defs)),
+		closure:  make(map[string][]string, len(defs)),
+		bindings: p.Bindings,
+	}
+	for name := range defs {
+		names, err := expand(defs, name, map[string]bool{}, nil)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(names)
+		c.closure[name] = names
+	}
+	for name, names := range c.closure {
+		var perms []string
+		for _, n := range names {
+			perms = append(perms, defs[n].Permissions...)
+		}
+		c.roles[name] = perms
+	}
+
+	for _, b := range p.Bindings {
+		if b.Subject == "" {
+			return nil, fmt.Errorf("rbac: 角色绑定的主体不能为空")
+		}
+		for _, role := range b.Roles {
+			if _, ok := defs[role]; !ok {
+				return nil, fmt.Errorf("rbac: 主体 %s 绑定了未定义的角色 %s", b.Subject, role)
+			}
+		}
+	}
+	return c, nil
+}
+
+// expand 深度优先展开角色继承，path记录当前路径用于检测循环
+func expand(defs map[string]config.RoleConfig, name string, path map[string]bool, seen []string) ([]string, error) {
+	if path[name] {
+		return nil, fmt.Errorf("rbac: 角色 %s 存在循环继承", name)
+	}
+	for _, s := range seen {
+		if s == name {
+			return seen, nil
+		}
+	}
+
+	path[name] = true
+	defer delete(path, name)
+
+	seen = append(seen, name)
+	for _, parent := range defs[name].Inherits {
+		if _, ok := defs[parent]; !ok {
+			return nil, fmt.Errorf("rbac: 角色 %s 继承了未定义的角色 %s", name, parent)
+		}
+		var err error
+		if seen, err = expand(defs, parent, path, seen); err != nil {
+			return nil, err
+		}
+	}
+	return seen, nil
+}
+
+// check 依次检查主体的角色，首个包含匹配规则的角色授予权限
+func (c *compiled) check(subject, permission string) Decision {
+	d := Decision{Subject: subject, Permission: permission, Roles: c.subjectRoles(subject)}
+
+	switch {
+	case subject == "":
+		d.Reason = "未认证"
+		return d
+	case len(d.Roles) == 0:
+		d.Reason = "主体没有绑定任何角色"
+		return d
+	}
+
+	for _, role := range d.Roles {
+		for _, rule := range c.roles[role] {
+			if Match(rule, permission) {
+				d.Allowed = true
+				d.Role = role
+				d.Rule = rule
+				d.Reason = fmt.Sprintf("角色 %s 的规则 %s 授予了该权限", role, rule)
+				return d
+			}
+		}
+	}
+	d.Reason = "主体的角色均未授予该权限"
+	return d
+}
+
+// subjectRoles 主体绑定的全部角色，包括继承的角色
+func (c *compiled) subjectRoles(subject string) []string {
+	if subject == "" {
+		return nil
+	}
+
+	set := map[string]bool{}
+	for _, b := range c.bindings {
+		if !matchSubject(b.Subject, subject) {
+			continue
+		}
+		for _, role := range b.Roles {
+			for _, name := range c.closure[role] {
+				set[name] = true
+			}
+		}
+	}
+
+	roles := make([]string, 0, len(set))
+	for name := range set {
+		roles = append(roles, name)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// matchSubject 匹配角色绑定的主体，末尾的 * 按前缀匹配
+func matchSubject(pattern, subject string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(subject, prefix)
+	}
+	return pattern == subject
+}
+
+// Match 判断权限规则是否匹配指定权限
+// 规则和权限均按冒号分段，* 匹配任意单段，规则末尾的 * 匹配其余全部分段（至少一段）
+func Match(rule, permission string) bool {
+	ruleParts := strings.Split(rule, ":")
+	permParts := strings.Split(permission, ":")
+	for i, part := range ruleParts {
+		if i >= len(permParts) {
+			return false
+		}
+		if part == "*" {
+			if i == len(ruleParts)-1 {
+				return true
+			}
+			continue
+		}
+		if part != permParts[i] {
+			return false
+		}
+	}
+	return len(ruleParts) == len(permParts)
+}
@@ -0,0 +1,121 @@
+package rbac
+
+import (
+	"FastGin/pkg/auth"
+	"FastGin/pkg/config"
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		rule, permission string
+		want             bool
+	}{
+		{"order:create", "order:create", true},
+		{"order:create", "order:cancel", false},
+		{"order:create", "order:create:bulk", false},
+		{"order:create", "order", false},
+		{"admin:*", "admin:proxy", true},
+		{"admin:*", "admin:proxy:read", true},
+		{"admin:*", "admin", false}, // 末尾的 * 至少匹配一段
+		{"admin:*", "administrator:read", false},
+		{"admin:*:read", "admin:proxy:read", true},
+		{"admin:*:read", "admin:proxy:write", false},
+		{"admin:*:read", "admin:proxy:read:all", false},
+		{"admin:*:read", "admin:read", false},
+		{"*", "order:create", true},
+		{"*:read", "cache:read", true},
+		{"*:read", "cache:write", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.rule, tt.permission); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.rule, tt.permission, got, tt.want)
+		}
+	}
+}
+
+// newTestEnforcer viewer只读，admin继承viewer并拥有全部admin权限，report:read只由viewer授予
+func newTestEnforcer(t *testing.T, bindings ...config.RoleBinding) *Enforcer {
+	t.Helper()
+	e := NewEnforcer(FromConfig(config.RBACConfig{
+		Roles: []config.RoleConfig{
+			{Name: "viewer", Permissions: []string{"admin:*:read", "report:read"}},
+			{Name: "admin", Inherits: []string{"viewer"}, Permissions: []string{"admin:*"}},
+			{Name: "orders", Permissions: []string{"order:create"}},
+		},
+		Bindings: bindings,
+	}))
+	if err := e.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestCheck(t *testing.T) {
+	e := newTestEnforcer(t,
+		config.RoleBinding{Subject: auth.APIKeyPrincipal("ops"), Roles: []string{"admin"}},
+		config.RoleBinding{Subject: auth.UserPrincipal("alice"), Roles: []string{"viewer"}},
+		config.RoleBinding{Subject: "partner:*", Roles: []string{"orders"}},
+	)
+
+	tests := []struct {
+		name       string
+		subject    string
+		permission string
+		wantAllow  bool
+		wantRole   string
+		wantRoles  []string
+	}{
+		{"继承的权限", auth.APIKeyPrincipal("ops"), "report:read", true, "admin", []string{"admin", "viewer"}},
+		{"自身角色授予权限", auth.APIKeyPrincipal("ops"), "admin:cache:write", true, "admin", []string{"admin", "viewer"}},
+		{"只读角色不能写", auth.UserPrincipal("alice"), "admin:cache:write", false, "", []string{"viewer"}},
+		{"前缀绑定", auth.PartnerPrincipal("acme"), "order:create", true, "orders", []string{"orders"}},
+		{"未绑定的主体", auth.UserPrincipal("bob"), "admin:proxy:read", false, "", []string{}},
+		{"未认证", "", "admin:proxy:read", false, "", nil},
+		// 用户名与API密钥主体同名时不能获得密钥的角色
+		{"用户名为key:ops", auth.UserPrincipal("key:ops"), "admin:proxy:read", false, "", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := e.Check(tt.subject, tt.permission)
+			if d.Allowed != tt.wantAllow || d.Role != tt.wantRole || !slices.Equal(d.Roles, tt.wantRoles) {
+				t.Fatalf("decision %+v", d)
+			}
+			if d.Reason == "" {
+				t.Error("decision has no reason")
+			}
+		})
+	}
+}
+
+func TestCheckBeforeLoad(t *testing.T) {
+	e := NewEnforcer(nil)
+	if d := e.Check(auth.APIKeyPrincipal("ops"), "admin:proxy:read"); d.Allowed {
+		t.Fatalf("decision %+v, want denied before policy is loaded", d)
+	}
+}
+
+func TestReloadKeepsPolicyOnError(t *testing.T) {
+	policy := &Policy{
+		Roles:    []config.RoleConfig{{Name: "admin", Permissions: []string{"admin:*"}}},
+		Bindings: []config.RoleBinding{{Subject: "key:ops", Roles: []string{"admin"}}},
+	}
+	e := NewEnforcer(LoaderFunc(func(context.Context) (*Policy, error) { return policy, nil }))
+	if err := e.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 循环继承的策略无效，保留原有策略
+	policy = &Policy{Roles: []config.RoleConfig{
+		{Name: "a", Inherits: []string{"b"}},
+		{Name: "b", Inherits: []string{"a"}},
+	}}
+	if err := e.Reload(context.Background()); err == nil {
+		t.Fatal("cyclic policy loaded")
+	}
+	if !e.Check("key:ops", "admin:proxy:read").Allowed {
+		t.Fatal("previous policy discarded")
+	}
+}
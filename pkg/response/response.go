@@ -0,0 +1,111 @@
+package response
+
+import (
+	"FastGin/pkg/i18n"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CodeOK 业务成功码，与HTTP状态码相互独立
+const CodeOK = 0
+
+// MsgOK 业务成功默认提示
+const MsgOK = "成功"
+
+// MsgOKKey 业务成功提示的国际化消息键
+const MsgOKKey = "success"
+
+// SensitiveKey 标记响应包含凭据的上下文键
+const SensitiveKey = "SensitiveResponse"
+
+// Response 统一响应结构
+type Response struct {
+	Code      int         `json:"code"`
+	Message   string      `json:"message"`
+	Data      interface{} `json:"data"`
+	RequestID string      `json:"request_id,omitempty"`
+	TraceID   string      `json:"trace_id,omitempty"`
+}
+
+// PageData 分页数据结构
+type PageData struct {
+	List     interface{} `json:"list"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+	Total    int64       `json:"total"`
+}
+
+// New 构建附带请求ID和追踪ID的响应结构
+func New(c *gin.Context, code int, message string, data interface{}) Response {
+	requestID := c.GetString("RequestID")
+	traceID := c.GetString("TraceID")
+	if traceID == "" {
+		traceID = requestID
+	}
+	return Response{
+		Code:      code,
+		Message:   message,
+		Data:      data,
+		RequestID: requestID,
+		TraceID:   traceID,
+	}
+}
+
+// JSON 以指定HTTP状态码输出响应结构
+func JSON(c *gin.Context, status, code int, message string, data interface{}) {
+	c.JSON(status, New(c, code, message, data))
+}
+
+// OK 成功响应
+func OK(c *gin.Context, data interface{}) {
+	JSON(c, http.StatusOK, CodeOK, i18n.T(c, MsgOKKey, MsgOK), data)
+}
+
+// Sensitive 标记当前响应包含凭据（如新创建的API密钥明文），日志中间件不会记录响应体
+func Sensitive(c *gin.Context) {
+	c.Set(SensitiveKey, true)
+}
+
+// Created 资源创建成功响应
+func Created(c *gin.Context, data interface{}) {
+	JSON(c, http.StatusCreated, CodeOK, i18n.T(c, MsgOKKey, MsgOK), data)
+}
+
+// Paginated 分页列表响应
+func Paginated(c *gin.Context, list interface{}, page, pageSize int, total int64) {
+	OK(c, PageData{
+		List:     list,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// Fail 失败响应，status为HTTP状态码，code为业务错误码，并中止后续处理
+func Fail(c *gin.Context, status, code int, message string) {
+	FailWithData(c, status, code, message, nil)
+}
+
+// FailWithData 携带数据的失败响应
+func FailWithData(c *gin.Context, status, code int, message string, data interface{}) {
+	c.AbortWithStatusJSON(status, New(c, code, message, data))
+}
+
+// Stream 以SSE方式推送数据，next返回false时结束推送
+// 每条事件都包装为统一响应结构，客户端断开时自动停止
+func Stream(c *gin.Context, next func() (interface{}, bool)) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	c.Stream(func(w io.Writer) bool {
+		data, ok := next()
+		if !ok {
+			return false
+		}
+		c.SSEvent("message", New(c, CodeOK, i18n.T(c, MsgOKKey, MsgOK), data))
+		return true
+	})
+}
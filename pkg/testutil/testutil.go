@@ -0,0 +1,62 @@
+// Package testutil 各包测试共用的初始化和上游配置
+package testutil
+
+import (
+	"FastGin/pkg/config"
+	"FastGin/pkg/logg"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Main 将日志写入临时目录后运行测试并退出，供各包的 TestMain 调用，避免在包目录下生成 logs
+func Main(m *testing.M) {
+	dir, err := os.MkdirTemp("", "fastgin-test-")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	cfg := logg.DefaultConfig()
+	cfg.LogDir = dir
+	cfg.EnableColor = false
+	if err := logg.InitLogger(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	gin.SetMode(gin.TestMode)
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// ReplayUpstream 回放path处录制文件的示例上游配置，请求头和签名方式与生成录制文件时一致
+func ReplayUpstream(path string) config.UpstreamConfig {
+	return config.UpstreamConfig{
+		BaseURL: "https://capi.lkcoffee.com",
+		Headers: map[string]string{"User-Agent": "okhttp/4.9.3", "x-lk-akv": "5205"},
+		Sign: config.SignConfig{
+			Type:           "md5-sorted",
+			Secret:         "test-secret",
+			SignParam:      "sign",
+			TimestampParam: "t",
+			TimestampUnit:  "ms",
+		},
+		Cassette: config.CassetteConfig{Mode: "replay", Path: path},
+	}
+}
+
+// InTempDir 切换到临时目录运行当前测试，结束后恢复，用于会在当前目录创建logs的日志中间件
+func InTempDir(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
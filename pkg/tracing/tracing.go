@@ -0,0 +1,122 @@
+package tracing
+
+import (
+	"FastGin/pkg/config"
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName 本项目创建的span使用的instrumentation名称
+const TracerName = "FastGin"
+
+// DefaultServiceName 未配置 service_name 时的服务名
+const DefaultServiceName = "fastgin"
+
+func init() {
+	// 未启用追踪时也透传上游的W3C追踪头，下游仍能关联到同一条链路
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+// Init 根据配置初始化全局TracerProvider，返回的shutdown需在退出前调用以导出剩余的span
+// exporter为nil时按配置创建，测试可传入 tracetest.NewInMemoryExporter()；未启用时不做任何事
+func Init(ctx context.Context, cfg config.TracingConfig, exporter sdktrace.SpanExporter) (shutdown func(context.Context) error, err error) {
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = DefaultServiceName
+	}
+	ratio := 1.0
+	if cfg.SampleRatio != nil {
+		ratio = *cfg.SampleRatio
+	}
+	if ratio < 0 || ratio > 1 {
+		return nil, fmt.Errorf("tracing: 采样比例 %v 超出 0~1", ratio)
+	}
+
+	if exporter == nil {
+		if exporter, err = newExporter(ctx, cfg); err != nil {
+			return nil, err
+		}
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("tracing: 创建资源失败: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// newExporter 按配置创建span导出器
+func newExporter(ctx context.Context, cfg config.TracingConfig) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case "", "otlp-http":
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case "otlp-grpc":
+		var opts []otlptracegrpc.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("tracing: 未知的导出器 %q", cfg.Exporter)
+	}
+}
+
+// Tracer 返回本项目使用的Tracer，始终从全局TracerProvider获取，Init之前创建的span为空操作
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// LogFields 将当前span的 trace_id 和 span_id 加入日志字段，没有有效span时原样返回fields
+//
+//	logg.Info("订单已创建", tracing.LogFields(ctx, map[string]interface{}{"order_id": id}))
+func LogFields(ctx context.Context, fields map[string]interface{}) map[string]interface{} {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return fields
+	}
+	if fields == nil {
+		fields = make(map[string]interface{}, 2)
+	}
+	fields["trace_id"] = sc.TraceID().String()
+	fields["span_id"] = sc.SpanID().String()
+	return fields
+}
@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NonceStore 随机串存储，用于拒绝重放的回调请求
+type NonceStore interface {
+	// Claim 原子地记录随机串，返回调用前是否尚未记录，until之后记录可以删除
+	Claim(ctx context.Context, nonce string, until time.Time) (bool, error)
+}
+
+// NewNonceStore 根据配置创建随机串存储，redis存储使用传入的客户端
+func NewNonceStore(store string, client *redis.Client) (NonceStore, error) {
+	switch store {
+	case "", "memory":
+		return NewMemoryNonceStore(), nil
+	case "redis":
+		if client == nil {
+			return nil, fmt.Errorf("webhook: 随机串存储需要配置 redis")
+		}
+		return NewRedisNonceStore(client), nil
+	default:
+		return nil, fmt.Errorf("webhook: 未知的随机串存储 %q", store)
+	}
+}
+
+// MemoryNonceStore 进程内随机串存储，仅适用于单实例部署
+type MemoryNonceStore struct {
+	mu        sync.Mutex
+	nonces    map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryNonceStore 创建进程内随机串存储
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: make(map[string]time.Time)}
+}
+
+// Claim 原子地记录随机串
+func (s *MemoryNonceStore) Claim(_ context.Context, nonce string, until time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep()
+	if existing, ok := s.nonces[nonce]; ok && time.Now().Before(existing) {
+		return false, nil
+	}
+	s.nonces[nonce] = until
+	return true, nil
+}
+
+// sweep 每分钟最多清理一次过期记录，调用方需持有锁
+func (s *MemoryNonceStore) sweep() {
+	now := time.Now()
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for nonce, until := range s.nonces {
+		if now.After(until) {
+			delete(s.nonces, nonce)
+		}
+	}
+}
+
+// redisNoncePrefix Redis中随机串的键前缀
+const redisNoncePrefix = "webhook:nonce:"
+
+// RedisNonceStore 基于Redis协议的随机串存储，记录随时间窗口自动删除
+type RedisNonceStore struct {
+	client *redis.Client
+}
+
+// NewRedisNonceStore 使用已有的Redis客户端创建随机串存储
+func NewRedisNonceStore(client *redis.Client) *RedisNonceStore {
+	return &RedisNonceStore{client: client}
+}
+
+// Claim 通过SET NX原子地记录随机串
+func (s *RedisNonceStore) Claim(ctx context.Context, nonce string, until time.Time) (bool, error) {
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		// 时间戳已离开允许范围，验证阶段会拒绝，这里不再记录
+		return true, nil
+	}
+	return s.client.SetNX(ctx, redisNoncePrefix+nonce, 1, ttl).Result()
+}
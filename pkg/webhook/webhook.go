@@ -0,0 +1,149 @@
+package webhook
+
+import (
+	"FastGin/pkg/config"
+	"FastGin/pkg/errcode"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 合作方回调携带的请求头
+const (
+	HeaderPartner   = "X-Partner-ID"
+	HeaderTimestamp = "X-Timestamp" // Unix时间戳，单位秒
+	HeaderNonce     = "X-Nonce"     // 随机串，时间窗口内不可重复
+	HeaderSignature = "X-Signature" // 小写十六进制HMAC-SHA256，可带 sha256= 前缀
+)
+
+// ContextKey 验证中间件写入gin.Context的合作方ID键
+const ContextKey = "Partner"
+
+// DefaultSkew 默认允许的时间戳偏差
+const DefaultSkew = 5 * time.Minute
+
+// maxNonceLength 随机串的最大长度
+const maxNonceLength = 128
+
+// Request 待验证的回调请求
+type Request struct {
+	Method    string
+	URI       string // 路径和查询参数，即 URL.RequestURI()
+	Partner   string
+	Timestamp string
+	Nonce     string
+	Signature string
+	Body      []byte
+}
+
+// Verifier 合作方回调签名验证
+type Verifier struct {
+	skew     time.Duration
+	partners map[string][]string
+	nonces   NonceStore
+}
+
+// NewVerifier 根据配置创建验证器，nonces为nil时使用进程内随机串存储
+func NewVerifier(cfg config.WebhookConfig, nonces NonceStore) (*Verifier, error) {
+	if cfg.Skew <= 0 {
+		cfg.Skew = DefaultSkew
+	}
+	if nonces == nil {
+		nonces = NewMemoryNonceStore()
+	}
+
+	v := &Verifier{
+		skew:     cfg.Skew,
+		partners: make(map[string][]string, len(cfg.Partners)),
+		nonces:   nonces,
+	}
+	for _, p := range cfg.Partners {
+		if p.ID == "" {
+			return nil, fmt.Errorf("webhook: 合作方ID不能为空")
+		}
+		if _, ok := v.partners[p.ID]; ok {
+			return nil, fmt.Errorf("webhook: 合作方 %s 重复配置", p.ID)
+		}
+		if len(p.Secrets) == 0 {
+			return nil, fmt.Errorf("webhook: 合作方 %s 未配置密钥", p.ID)
+		}
+		for _, secret := range p.Secrets {
+			if secret == "" {
+				return nil, fmt.Errorf("webhook: 合作方 %s 包含空密钥", p.ID)
+			}
+		}
+		v.partners[p.ID] = p.Secrets
+	}
+	return v, nil
+}
+
+// Verify 依次验证合作方、时间戳、签名和随机串，签名通过后才记录随机串，避免伪造请求占用随机串
+// 签名无效返回errcode.SignatureInvalid，时间戳超出允许范围返回errcode.SignatureExpired，随机串重复返回errcode.RequestReplayed
+func (v *Verifier) Verify(ctx context.Context, r *Request) error {
+	secrets, ok := v.partners[r.Partner]
+	if !ok {
+		return errcode.SignatureInvalid.Wrap(fmt.Errorf("webhook: 未知的合作方 %q", r.Partner))
+	}
+
+	ts, err := strconv.ParseInt(r.Timestamp, 10, 64)
+	if err != nil {
+		return errcode.SignatureInvalid.Wrap(fmt.Errorf("webhook: 时间戳 %q 无效", r.Timestamp))
+	}
+	if skew := time.Since(time.Unix(ts, 0)); math.Abs(float64(skew)) > float64(v.skew) {
+		return errcode.SignatureExpired.Wrap(fmt.Errorf("webhook: 时间戳偏差 %s 超过 %s", skew.Round(time.Second), v.skew))
+	}
+	if r.Nonce == "" || len(r.Nonce) > maxNonceLength {
+		return errcode.SignatureInvalid.Wrap(fmt.Errorf("webhook: 随机串为空或过长"))
+	}
+
+	got, err := hex.DecodeString(strings.TrimPrefix(strings.ToLower(r.Signature), "sha256="))
+	if err != nil || len(got) != sha256.Size {
+		return errcode.SignatureInvalid.Wrap(fmt.Errorf("webhook: 签名格式无效"))
+	}
+	matched := false
+	for _, secret := range secrets {
+		if hmac.Equal(got, mac(secret, r)) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return errcode.SignatureInvalid.Wrap(fmt.Errorf("webhook: 合作方 %s 的签名不匹配", r.Partner))
+	}
+
+	// 随机串保留到时间戳离开允许范围为止，之后的重放会被时间戳检查拒绝
+	first, err := v.nonces.Claim(ctx, r.Partner+":"+r.Nonce, time.Unix(ts, 0).Add(v.skew))
+	if err != nil {
+		return errcode.InternalServerError.Wrap(err)
+	}
+	if !first {
+		return errcode.RequestReplayed.Wrap(fmt.Errorf("webhook: 合作方 %s 的随机串 %s 已使用", r.Partner, r.Nonce))
+	}
+	return nil
+}
+
+// Sign 计算回调签名，供合作方或测试生成请求
+// 签名串为 "方法\n路径和查询参数\n时间戳\n随机串\n请求体"，结果为小写十六进制HMAC-SHA256
+func Sign(secret string, r *Request) string {
+	return hex.EncodeToString(mac(secret, r))
+}
+
+func mac(secret string, r *Request) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(r.Method + "\n" + r.URI + "\n" + r.Timestamp + "\n" + r.Nonce + "\n"))
+	h.Write(r.Body)
+	return h.Sum(nil)
+}
+
+// PartnerFrom 读取验证通过的合作方ID，未经过验证中间件时返回空串
+func PartnerFrom(c *gin.Context) string {
+	return c.GetString(ContextKey)
+}
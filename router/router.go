@@ -2,29 +2,106 @@ package router
 
 import (
 	//"FastGin/api/example"
+	"FastGin/api/admin"
+	healthapi "FastGin/api/health"
+	"FastGin/api/security"
 	"FastGin/api/test"
+	webhookapi "FastGin/api/webhook"
+	"FastGin/apiServer"
 	"FastGin/middleware"
+	"FastGin/pkg/auth"
+	"FastGin/pkg/bind"
+	"FastGin/pkg/config"
+	"FastGin/pkg/errcode"
+	"FastGin/pkg/httpclient"
+	"FastGin/pkg/logg"
+	"FastGin/pkg/metrics"
+	"FastGin/pkg/rbac"
+	"FastGin/pkg/webhook"
 
 	"github.com/gin-gonic/gin"
 )
 
-func InitRouter() *gin.Engine {
+// InitRouter 初始化路由，cfg 为应用配置，clients 为各上游服务的共享HTTP客户端，authenticator 用于签发和验证JWT，
+// users 用于校验登录密码，apiKeys 用于验证服务间调用的API密钥，webhooks 用于验证合作方回调签名，guard 用于登录失败保护
+func InitRouter(cfg *config.Config, clients *httpclient.Manager, authenticator *auth.Authenticator, users auth.CredentialVerifier, apiKeys *auth.APIKeys, webhooks *webhook.Verifier, guard *auth.LoginGuard) *gin.Engine {
+	// 注册自定义校验规则
+	bind.Init()
+
 	r := gin.New()
+	// 仅信任配置的反向代理转发的X-Forwarded-For，避免客户端伪造IP绕过按IP的限流和登录保护
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		logg.Error("配置可信代理失败:", err)
+		panic(err)
+	}
 	// 使用中间件
-	r.Use(gin.Recovery())
 	r.Use(middleware.RequestID())        // 添加请求ID中间件
+	r.Use(middleware.Tracing())          // 链路追踪
+	r.Use(middleware.Metrics())          // 请求指标
 	r.Use(middleware.LoggerMiddleware()) // 添加日志中间件
+	r.Use(middleware.Recovery())         // panic恢复
+	r.Use(middleware.I18n())             // 语言协商
+	r.Use(middleware.ErrorHandler())     // 统一错误响应
 	r.Use(middleware.Cors())
+	r.Use(middleware.SecurityHeaders(cfg.SecurityHeaders)) // 安全响应头
+
+	// 存活和就绪检查注册在限流之前，探针请求不会因限流被拒绝而导致实例被摘除
+	healthHandler := healthapi.NewHandler()
+	r.GET("/healthz", errcode.Handle(healthHandler.Healthz)) // 存活检查
+	r.GET("/readyz", errcode.Handle(healthHandler.Readyz))   // 就绪检查
+
 	//限流操作
 	r.Use(middleware.RateLimit(300, 500)) // 每秒最多处理x个请求，突发最大y个
 
 	// API 路由组
 	apiGroup := r.Group("/api")
 	// 创建处理器实例
-	testHandler := test.NewHandler()
+	testHandler := test.NewHandler(apiServer.NewExampleService(clients.Client(apiServer.ExampleUpstream)), authenticator, users, guard)
 	{
 		// test service 相关路由
-		apiGroup.POST("/login", testHandler.Login) // 登录接口
+		apiGroup.POST("/login", errcode.Handle(testHandler.Login))           // 登录接口
+		apiGroup.POST("/token/refresh", errcode.Handle(testHandler.Refresh)) // 刷新令牌
+	}
+
+	// 需要登录的路由组
+	authGroup := apiGroup.Group("", middleware.Auth(authenticator))
+	{
+		authGroup.POST("/logout", errcode.Handle(testHandler.Logout)) // 退出登录
+	}
+
+	// 健康检查详情，包含版本和依赖状态，需要认证
+	r.GET("/health/details", middleware.AuthOrAPIKey(authenticator, apiKeys), middleware.APIKeyRateLimit(), rbac.Require("admin:health:read"), errcode.Handle(healthHandler.Details))
+
+	// Prometheus指标，配置了独立监听地址时由main在该地址提供
+	if cfg.Metrics.Enabled && cfg.Metrics.Addr == "" {
+		r.GET(cfg.Metrics.ScrapePath(), gin.WrapH(metrics.Handler()))
+	}
+
+	// 浏览器上报的CSP违规报告
+	securityHandler := security.NewHandler()
+	r.POST("/csp-report", errcode.Handle(securityHandler.CSPReport))
+
+	// 合作方回调路由组，全部接口都需要通过签名验证
+	webhookGroup := r.Group("/webhooks", middleware.Webhook(webhooks))
+	webhookHandler := webhookapi.NewHandler()
+	{
+		webhookGroup.POST("/ping", errcode.Handle(webhookHandler.Ping)) // 签名联调
+	}
+
+	// 运维管理路由组，接受用户JWT和访问范围包含 /admin 的API密钥，各接口按权限授权
+	adminGroup := r.Group("/admin", middleware.AuthOrAPIKey(authenticator, apiKeys), middleware.APIKeyRateLimit())
+	adminHandler := admin.NewHandler(clients, apiKeys, guard)
+	{
+		adminGroup.GET("/proxies", rbac.Require("admin:proxy:read"), errcode.Handle(adminHandler.Proxies))                            // 出口代理统计
+		adminGroup.GET("/upstreams/:name/debug", rbac.Require("admin:upstream:read"), errcode.Handle(adminHandler.UpstreamDebug))     // 上游调试日志开关
+		adminGroup.PUT("/upstreams/:name/debug", rbac.Require("admin:upstream:write"), errcode.Handle(adminHandler.SetUpstreamDebug)) // 切换上游调试日志
+		adminGroup.GET("/cache", rbac.Require("admin:cache:read"), errcode.Handle(adminHandler.CacheStats))                           // 响应缓存统计
+		adminGroup.DELETE("/cache/:name", rbac.Require("admin:cache:write"), errcode.Handle(adminHandler.PurgeCache))                 // 清除响应缓存
+		adminGroup.GET("/api-keys", rbac.Require("admin:apikey:read"), errcode.Handle(adminHandler.APIKeys))                          // API密钥列表
+		adminGroup.POST("/api-keys", rbac.Require("admin:apikey:write"), errcode.Handle(adminHandler.CreateAPIKey))                   // 创建API密钥
+		adminGroup.DELETE("/api-keys/:id", rbac.Require("admin:apikey:write"), errcode.Handle(adminHandler.RevokeAPIKey))             // 吊销API密钥
+		adminGroup.GET("/rbac/check", rbac.Require("admin:rbac:read"), errcode.Handle(adminHandler.RBACCheck))                        // 排查权限检查结果
+		adminGroup.DELETE("/login-locks", rbac.Require("admin:login:write"), errcode.Handle(adminHandler.UnlockLogin))                // 解除登录锁定
 	}
 
 	return r